@@ -0,0 +1,166 @@
+package nerrors
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// IncidentIDField is the key of the field with the incident ID of the errors sanitised by a Boundary.
+const IncidentIDField = "incident_id"
+
+// Boundary with the policy applied to the errors that leave the trust boundary of a service, for example the ones
+// sent to external customers. The zero value strips the stack traces, the causes and the details, and keeps the codes
+// and the messages.
+type Boundary struct {
+	// PublicMessages with the message sent instead of the one of the error for each code. The original code is
+	// checked first, then the public one. The message of the error is sent for the codes not found.
+	PublicMessages map[ErrorCode]string
+	// PublicCodes with the code sent for each internal code (e.g., DataLoss to Internal).
+	PublicCodes map[ErrorCode]ErrorCode
+	// KeepStackTraces sends the stack traces of the errors.
+	KeepStackTraces bool
+	// KeepCauses sends the errors that caused the sanitised one, with their codes remapped.
+	KeepCauses bool
+	// KeepDetails sends the protobuf details attached to the error chain (see ExtendedError.WithDetails).
+	KeepDetails bool
+	// NewIncidentID generates the incident IDs. A random ID is generated if it is not set.
+	NewIncidentID func() string
+	// Log receives the full internal error with its incident ID. The error is logged with the standard logger if it
+	// is not set.
+	Log func(incidentID string, err *ExtendedError)
+}
+
+// NewBoundary creates a Boundary that remaps DataLoss and Unknown to Internal, and hides the messages of the errors
+// related to failures of the server.
+func NewBoundary() *Boundary {
+	return &Boundary{
+		PublicMessages: map[ErrorCode]string{
+			Internal:         "internal error",
+			Unavailable:      "service unavailable",
+			DeadlineExceeded: "deadline exceeded",
+		},
+		PublicCodes: map[ErrorCode]ErrorCode{
+			DataLoss: Internal,
+			Unknown:  Internal,
+		},
+	}
+}
+
+// Sanitize returns the error to send outside of the boundary. The sanitised error carries a generated incident ID,
+// which is logged together with the full internal error. Unless the causes are kept, the message is the one of the
+// first extended error of the chain without the messages of its causes.
+func (b *Boundary) Sanitize(err error) *ExtendedError {
+	if err == nil {
+		return nil
+	}
+	internal := FromError(err)
+	incidentID := b.incidentID()
+	b.log(incidentID, internal)
+
+	sanitized := b.sanitizeLink(internal)
+	if !b.KeepCauses {
+		sanitized.Msg = linkMessage(err, sanitized.Code)
+	}
+	sanitized.retryAfter = internal.RetryAfter()
+	if b.KeepDetails {
+		sanitized.details = internal.Details()
+	}
+	if message, exists := b.PublicMessages[internal.Code]; exists {
+		sanitized.Msg = message
+	} else if message, exists := b.PublicMessages[sanitized.Code]; exists {
+		sanitized.Msg = message
+	}
+	return sanitized.WithField(IncidentIDField, incidentID)
+}
+
+// sanitizeLink copies an error of the chain applying the policy. Fields are not copied.
+func (b *Boundary) sanitizeLink(ee *ExtendedError) *ExtendedError {
+	sanitized := &ExtendedError{Code: b.publicCode(ee.Code), Msg: ee.Msg}
+	if b.KeepStackTraces {
+		sanitized.frames = ee.Frames()
+	}
+	if b.KeepCauses && ee.From != nil {
+		if list, ok := ee.From.(*ErrorList); ok {
+			sanitizedList := &ErrorList{}
+			for _, err := range list.Errors {
+				sanitizedList.Errors = append(sanitizedList.Errors, b.sanitizeLink(causeFromError(err)))
+			}
+			sanitized.From = sanitizedList
+		} else {
+			sanitized.From = b.sanitizeLink(causeFromError(ee.From))
+		}
+	}
+	return sanitized
+}
+
+// linkMessage returns the message of the first extended error of the chain, without the messages of its causes. A
+// generic message is returned if there is none, as the text of the other errors includes the one of the errors they
+// wrap. The lists of errors end the chain.
+func linkMessage(err error, code ErrorCode) string {
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		if extended, ok := current.(*ExtendedError); ok && extended.Msg != "" {
+			return extended.Msg
+		}
+	}
+	return genericMessage(code)
+}
+
+// genericMessage returns the message sent for the errors with a code and no message of their own.
+func genericMessage(code ErrorCode) string {
+	return fmt.Sprintf("request failed with code %s", code.String())
+}
+
+// publicCode returns the code sent for an internal code.
+func (b *Boundary) publicCode(code ErrorCode) ErrorCode {
+	if public, exists := b.PublicCodes[code]; exists {
+		return public
+	}
+	return code
+}
+
+// incidentID generates a new incident ID.
+func (b *Boundary) incidentID() string {
+	if b.NewIncidentID != nil {
+		return b.NewIncidentID()
+	}
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// log logs the internal error with its incident ID.
+func (b *Boundary) log(incidentID string, err *ExtendedError) {
+	if b.Log != nil {
+		b.Log(incidentID, err)
+		return
+	}
+	log.Printf("incident %s: %s", incidentID, err.StackTraceToString())
+}
+
+// WriteError writes the sanitised error as a JSON response with WriteError.
+func (b *Boundary) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	WriteError(w, r, b.Sanitize(err))
+}
+
+// WriteProblem writes the sanitised error as problem details with WriteProblem.
+func (b *Boundary) WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	WriteProblem(w, r, b.Sanitize(err))
+}
+
+// IncidentID returns the incident ID assigned to the error by a Boundary, or an empty string if it has none.
+func (ee *ExtendedError) IncidentID() string {
+	if id, ok := ee.Fields()[IncidentIDField].(string); ok {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package nerrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// loggedIncident with an incident received by the log function of a boundary.
+type loggedIncident struct {
+	id  string
+	err *ExtendedError
+}
+
+// testBoundary returns a boundary with predictable incident IDs that stores the logged incidents.
+func testBoundary(incidents *[]loggedIncident) *Boundary {
+	boundary := NewBoundary()
+	boundary.NewIncidentID = func() string {
+		return "incident-1"
+	}
+	boundary.Log = func(incidentID string, err *ExtendedError) {
+		*incidents = append(*incidents, loggedIncident{id: incidentID, err: err})
+	}
+	return boundary
+}
+
+var _ = ginkgo.Describe("Handler test on boundaries", func() {
+	var incidents []loggedIncident
+	var boundary *Boundary
+
+	ginkgo.BeforeEach(func() {
+		incidents = nil
+		boundary = testBoundary(&incidents)
+	})
+
+	ginkgo.It("replaces the messages and strips the stack traces and causes", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("table users not found"), "query failed on db-1").WithField("host", "db-1")
+		sanitized := boundary.Sanitize(err)
+		gomega.Expect(sanitized.Code).Should(gomega.Equal(Internal))
+		gomega.Expect(sanitized.Msg).Should(gomega.Equal("internal error"))
+		gomega.Expect(sanitized.From).Should(gomega.BeNil())
+		gomega.Expect(sanitized.Frames()).Should(gomega.BeNil())
+		gomega.Expect(sanitized.Fields()).Should(gomega.Equal(map[string]interface{}{IncidentIDField: "incident-1"}))
+		gomega.Expect(sanitized.IncidentID()).Should(gomega.Equal("incident-1"))
+	})
+	ginkgo.It("logs the full internal error with the incident ID", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("table users not found"), "query failed on db-1")
+		_ = boundary.Sanitize(err)
+		gomega.Expect(incidents).Should(gomega.Equal([]loggedIncident{{id: "incident-1", err: err}}))
+	})
+	ginkgo.It("remaps the internal codes", func() {
+		sanitized := boundary.Sanitize(NewDataLossError("corrupted block 42"))
+		gomega.Expect(sanitized.Code).Should(gomega.Equal(Internal))
+		gomega.Expect(sanitized.Msg).Should(gomega.Equal("internal error"))
+
+		boundary.PublicMessages[DataLoss] = "storage failure"
+		gomega.Expect(boundary.Sanitize(NewDataLossError("corrupted block 42")).Msg).Should(gomega.Equal("storage failure"))
+		gomega.Expect(boundary.Sanitize(errors.New("raw failure")).Code).Should(gomega.Equal(Internal))
+	})
+	ginkgo.It("keeps the messages of the codes without public message", func() {
+		sanitized := boundary.Sanitize(NewNotFoundError("app not found"))
+		gomega.Expect(sanitized.Code).Should(gomega.Equal(NotFound))
+		gomega.Expect(sanitized.Msg).Should(gomega.Equal("app not found"))
+	})
+	ginkgo.It("takes the message of the first extended error of a wrapped chain", func() {
+		err := fmt.Errorf("lookup failed: %w", NewNotFoundErrorFrom(fmt.Errorf("sql: select * from users where ssn=123-45-6789"), "user missing"))
+		sanitized := boundary.Sanitize(err)
+		gomega.Expect(sanitized.Code).Should(gomega.Equal(NotFound))
+		gomega.Expect(sanitized.Msg).Should(gomega.Equal("user missing"))
+		gomega.Expect(sanitized.From).Should(gomega.BeNil())
+		gomega.Expect(sanitized.String()).ShouldNot(gomega.ContainSubstring("sql"))
+		gomega.Expect(sanitized.String()).ShouldNot(gomega.ContainSubstring("ssn"))
+
+		raw := boundary.Sanitize(fmt.Errorf("lookup failed: %w", status.Error(codes.NotFound, "ssn=123-45-6789 not found")))
+		gomega.Expect(raw.Code).Should(gomega.Equal(NotFound))
+		gomega.Expect(raw.Msg).Should(gomega.Equal("request failed with code NotFound"))
+	})
+	ginkgo.It("keeps the stack traces and causes on request", func() {
+		boundary.KeepStackTraces = true
+		boundary.KeepCauses = true
+		err := NewInternalErrorFrom(Join(NewDataLossError("lost"), errors.New("raw")), "internal")
+		sanitized := boundary.Sanitize(err)
+		gomega.Expect(sanitized.Frames()).Should(gomega.Equal(err.Frames()))
+		list := sanitized.From.(*ErrorList)
+		gomega.Expect(list.Errors).Should(gomega.HaveLen(2))
+		gomega.Expect(list.Errors[0].(*ExtendedError).Code).Should(gomega.Equal(Internal))
+		gomega.Expect(list.Errors[0].(*ExtendedError).Msg).Should(gomega.Equal("lost"))
+		gomega.Expect(list.Errors[1].(*ExtendedError).Code).Should(gomega.Equal(Internal))
+	})
+	ginkgo.It("generates random incident IDs by default", func() {
+		boundary.NewIncidentID = nil
+		first := boundary.Sanitize(NewInternalError("internal")).IncidentID()
+		second := boundary.Sanitize(NewInternalError("internal")).IncidentID()
+		gomega.Expect(first).Should(gomega.HaveLen(16))
+		gomega.Expect(first).ShouldNot(gomega.Equal(second))
+	})
+	ginkgo.It("returns nil for nil errors", func() {
+		gomega.Expect(boundary.Sanitize(nil)).Should(gomega.BeNil())
+		gomega.Expect(incidents).Should(gomega.BeEmpty())
+	})
+
+	ginkgo.Context("HTTP", func() {
+		ginkgo.It("writes the sanitised error", func() {
+			handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return NewDataLossError("corrupted block 42")
+			})
+			response := serve(boundary.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				boundary.WriteError(w, r, handler(w, r))
+			})), http.MethodGet)
+			gomega.Expect(response.Code).Should(gomega.Equal(http.StatusInternalServerError))
+			body := &HTTPError{}
+			gomega.Expect(json.Unmarshal(response.Body.Bytes(), body)).Should(gomega.Succeed())
+			gomega.Expect(body).Should(gomega.Equal(&HTTPError{Code: "Internal", Message: "internal error", IncidentID: "incident-1"}))
+		})
+		ginkgo.It("sanitises the panics", func() {
+			response := serve(boundary.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("secret exploded")
+			})), http.MethodGet)
+			gomega.Expect(response.Body.String()).ShouldNot(gomega.ContainSubstring("secret"))
+			gomega.Expect(incidents).Should(gomega.HaveLen(1))
+			gomega.Expect(incidents[0].err.Msg).Should(gomega.ContainSubstring("secret exploded"))
+		})
+		ginkgo.It("writes the sanitised problem details", func() {
+			response := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				boundary.WriteProblem(w, r, NewUnavailableError("db-1 is down"))
+			}), http.MethodGet)
+			problem := &Problem{}
+			gomega.Expect(json.Unmarshal(response.Body.Bytes(), problem)).Should(gomega.Succeed())
+			gomega.Expect(problem.Detail).Should(gomega.Equal("service unavailable"))
+			gomega.Expect(problem.IncidentID).Should(gomega.Equal("incident-1"))
+			gomega.Expect(FromProblem(problem).IncidentID()).Should(gomega.Equal("incident-1"))
+		})
+	})
+
+	ginkgo.Context("gRPC", func() {
+		var service *failingHealthServer
+		var server *grpc.Server
+		var conn *grpc.ClientConn
+		var client grpc_health_v1.HealthClient
+
+		ginkgo.BeforeEach(func() {
+			service = &failingHealthServer{}
+			server, conn = testServer(service, []grpc.ServerOption{
+				grpc.UnaryInterceptor(UnaryServerInterceptor(WithBoundary(boundary))),
+				grpc.StreamInterceptor(StreamServerInterceptor(WithBoundary(boundary))),
+			})
+			client = grpc_health_v1.NewHealthClient(conn)
+		})
+		ginkgo.AfterEach(func() {
+			_ = conn.Close()
+			server.Stop()
+		})
+
+		ginkgo.It("sanitises the errors of unary calls", func() {
+			previous := GetConfig()
+			defer SetConfig(previous)
+			SetConfig(Config{FrameDetails: true})
+			service.err = NewDataLossErrorFrom(NewNotFoundError("block 42"), "corrupted storage")
+			_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.Internal))
+			received := FromGRPC(err)
+			gomega.Expect(received.Msg).Should(gomega.Equal("internal error"))
+			gomega.Expect(received.From).Should(gomega.BeNil())
+			gomega.Expect(received.Frames()).Should(gomega.BeNil())
+			gomega.Expect(received.IncidentID()).Should(gomega.Equal("incident-1"))
+			gomega.Expect(incidents).Should(gomega.HaveLen(1))
+			gomega.Expect(incidents[0].err).Should(gomega.Equal(service.err))
+		})
+		ginkgo.It("sanitises the status errors of stream calls", func() {
+			service.err = status.Error(codes.Unknown, "secret failure")
+			err := watchError(client)
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.Internal))
+			gomega.Expect(status.Convert(err).Message()).Should(gomega.Equal("internal error"))
+		})
+	})
+})
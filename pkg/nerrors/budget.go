@@ -0,0 +1,156 @@
+package nerrors
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// truncatedMessageSize with the number of bytes kept of the messages truncated to fit in the size of the details.
+const truncatedMessageSize = 64
+
+// fitStatus adds the details to the gRPC status, reducing them until the encoded status fits in maxSize bytes. The
+// stack traces are truncated first starting with the innermost error, then the messages of the causes, and finally
+// the links in the middle of the chain. Each reduction leaves a marker showing what was dropped. The trailing details
+// are added after the error frames, and they are never reduced. If the details still do not fit, for instance because
+// of the fields of the top-level error, the error frames are dropped, and then the trailing details, keeping only the
+// code and message of the status. A maxSize of zero or less disables the limit.
+func fitStatus(st *status.Status, details []*nerrorspb.ErrorFrame, maxSize int,
+	trailing ...*anypb.Any) (*status.Status, error) {
+	fitted, err := withFrames(st, details, trailing)
+	if err != nil || maxSize <= 0 || statusSize(fitted) <= maxSize {
+		return fitted, err
+	}
+	reductions := make([]func(), 0)
+	for i := len(details) - 1; i >= 0; i-- {
+		frame := details[i]
+		reductions = append(reductions, func() { truncateStack(frame) })
+	}
+	for i := len(details) - 1; i > 0; i-- {
+		frame := details[i]
+		reductions = append(reductions, func() { truncateMessage(frame) })
+	}
+	reduced := details
+	for dropped := 1; dropped < len(details); dropped++ {
+		dropped := dropped
+		reductions = append(reductions, func() { reduced = dropLinks(details, dropped) })
+	}
+	reductions = append(reductions, func() { reduced = nil })
+	for _, reduce := range reductions {
+		reduce()
+		fitted, err = withFrames(st, reduced, trailing)
+		if err != nil || statusSize(fitted) <= maxSize {
+			return fitted, err
+		}
+	}
+	return st, nil
+}
+
+// withFrames returns the gRPC status with the given error frames followed by the trailing details, which are added
+// verbatim.
+func withFrames(st *status.Status, details []*nerrorspb.ErrorFrame, trailing []*anypb.Any) (*status.Status, error) {
+	messages := make([]protoiface.MessageV1, len(details))
+	for i, detail := range details {
+		messages[i] = detail
+	}
+	framed, err := st.WithDetails(messages...)
+	if err != nil || len(trailing) == 0 {
+		return framed, err
+	}
+	encoded := framed.Proto()
+	encoded.Details = append(encoded.Details, trailing...)
+	return status.FromProto(encoded), nil
+}
+
+// statusSize returns the size in bytes of the encoded status.
+func statusSize(st *status.Status) int {
+	return proto.Size(st.Proto())
+}
+
+// truncateStack replaces the stack frames of a detail with a marker.
+func truncateStack(frame *nerrorspb.ErrorFrame) {
+	truncated := len(frame.StackFrames) + int(frame.SharedFrames)
+	if truncated == 0 {
+		return
+	}
+	frame.StackFrames = []*nerrorspb.StackFrame{{Function: fmt.Sprintf("... %d frames truncated", truncated)}}
+	frame.SharedFrames = 0
+}
+
+// truncateMessage shortens the message of a detail, indicating the number of bytes dropped.
+func truncateMessage(frame *nerrorspb.ErrorFrame) {
+	if len(frame.Message) <= truncatedMessageSize {
+		return
+	}
+	cut := truncatedMessageSize
+	for cut > 0 && !utf8.RuneStart(frame.Message[cut]) {
+		cut--
+	}
+	frame.Message = fmt.Sprintf("%s... (%d bytes truncated)", frame.Message[:cut], len(frame.Message)-cut)
+}
+
+// dropLinks returns a copy of the details where the given number of links after the top-level error are replaced by
+// a marker. The marker takes the code of the first dropped link, and links with the kept errors caused by the dropped
+// ones.
+func dropLinks(details []*nerrorspb.ErrorFrame, dropped int) []*nerrorspb.ErrorFrame {
+	// newIndex returns the position of a detail in the reduced details.
+	newIndex := func(index int32) int32 {
+		if index <= 0 {
+			return index
+		}
+		if int(index) <= dropped {
+			return 1
+		}
+		return index - int32(dropped) + 1
+	}
+	marker := &nerrorspb.ErrorFrame{
+		Code:       details[1].Code,
+		Message:    fmt.Sprintf("... %d errors truncated", dropped),
+		CauseIndex: -1,
+	}
+	for _, removed := range details[1 : dropped+1] {
+		for _, cause := range causeIndices(removed) {
+			if int(cause) > dropped {
+				marker.CauseIndices = append(marker.CauseIndices, newIndex(cause))
+			}
+		}
+	}
+	if len(marker.CauseIndices) == 1 {
+		marker.CauseIndex, marker.CauseIndices = marker.CauseIndices[0], nil
+	}
+
+	reduced := []*nerrorspb.ErrorFrame{details[0], marker}
+	reduced = append(reduced, details[dropped+1:]...)
+	for i, frame := range reduced {
+		if i == 1 {
+			continue
+		}
+		frame = proto.Clone(frame).(*nerrorspb.ErrorFrame)
+		frame.CauseIndex = newIndex(frame.CauseIndex)
+		indices := frame.CauseIndices
+		frame.CauseIndices = nil
+		for _, cause := range indices {
+			if index := newIndex(cause); len(frame.CauseIndices) == 0 || frame.CauseIndices[len(frame.CauseIndices)-1] != index {
+				frame.CauseIndices = append(frame.CauseIndices, index)
+			}
+		}
+		reduced[i] = frame
+	}
+	return reduced
+}
+
+// causeIndices returns the positions of the causes of a detail.
+func causeIndices(frame *nerrorspb.ErrorFrame) []int32 {
+	if len(frame.CauseIndices) > 0 {
+		return frame.CauseIndices
+	}
+	if frame.CauseIndex >= 0 {
+		return []int32{frame.CauseIndex}
+	}
+	return nil
+}
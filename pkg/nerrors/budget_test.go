@@ -0,0 +1,145 @@
+package nerrors
+
+import (
+	"strings"
+	"time"
+
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/status"
+)
+
+// longChain returns a chain with the given number of links, each one with a long message.
+func longChain(links int) *ExtendedError {
+	err := NewNotFoundError("root cause %s", strings.Repeat("r", 200))
+	for i := 1; i < links; i++ {
+		err = NewInternalErrorFrom(err, "link %d %s", i, strings.Repeat("m", 200))
+	}
+	return err
+}
+
+// chainMessages returns the messages of the extended errors of a chain.
+func chainMessages(err *ExtendedError) []string {
+	messages := make([]string, 0)
+	for _, link := range linearCauses(err) {
+		messages = append(messages, link.Msg)
+	}
+	return messages
+}
+
+var _ = ginkgo.Describe("Handler test on the size of the gRPC details", func() {
+	var previous Config
+
+	ginkgo.BeforeEach(func() {
+		previous = GetConfig()
+		SetConfig(Config{FrameDetails: true})
+	})
+	ginkgo.AfterEach(func() {
+		SetConfig(previous)
+	})
+
+	ginkgo.It("keeps the details that fit in the size", func() {
+		SetConfig(Config{MaxDetailsSize: 8192, FrameDetails: true})
+		err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal")
+		gomega.Expect(FromGRPC(err.ToGRPC())).Should(gomega.Equal(err))
+	})
+	ginkgo.It("truncates the inner stack traces first", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal")
+		full := statusSize(status.Convert(err.ToGRPC()))
+		SetConfig(Config{MaxDetailsSize: full - 1, FrameDetails: true})
+
+		converted := status.Convert(err.ToGRPC())
+		gomega.Expect(statusSize(converted)).Should(gomega.BeNumerically("<", full))
+		received := FromGRPC(converted.Err())
+		gomega.Expect(received.Frames()).Should(gomega.Equal(err.Frames()))
+		cause := received.From.(*ExtendedError)
+		gomega.Expect(cause.Msg).Should(gomega.Equal("not found"))
+		gomega.Expect(cause.Frames()).Should(gomega.HaveLen(1))
+		gomega.Expect(cause.Frames()[0].Function).Should(gomega.MatchRegexp(`^\.\.\. \d+ frames truncated$`))
+	})
+	ginkgo.It("truncates the messages of the causes", func() {
+		SetConfig(Config{Disabled: true, MaxDetailsSize: 900, FrameDetails: true})
+		err := longChain(3)
+		converted := status.Convert(err.ToGRPC())
+		gomega.Expect(statusSize(converted)).Should(gomega.BeNumerically("<=", 900))
+
+		received := FromGRPC(converted.Err())
+		gomega.Expect(received.Code).Should(gomega.Equal(err.Code))
+		gomega.Expect(received.Msg).Should(gomega.Equal(err.Msg))
+		messages := chainMessages(received)
+		gomega.Expect(messages).Should(gomega.HaveLen(3))
+		gomega.Expect(messages[2]).Should(gomega.HavePrefix("root cause rrr"))
+		gomega.Expect(messages[2]).Should(gomega.HaveSuffix("... (147 bytes truncated)"))
+	})
+	ginkgo.It("drops the links in the middle of the chain", func() {
+		SetConfig(Config{MaxDetailsSize: 1024, FrameDetails: true})
+		err := longChain(30)
+		converted := status.Convert(err.ToGRPC())
+		gomega.Expect(statusSize(converted)).Should(gomega.BeNumerically("<=", 1024))
+
+		received := FromGRPC(converted.Err())
+		gomega.Expect(received.Code).Should(gomega.Equal(Internal))
+		gomega.Expect(received.Msg).Should(gomega.Equal(err.Msg))
+		messages := chainMessages(received)
+		gomega.Expect(messages[1]).Should(gomega.MatchRegexp(`^\.\.\. \d+ errors truncated$`))
+		gomega.Expect(messages[len(messages)-1]).Should(gomega.HavePrefix("root cause"))
+		gomega.Expect(received.From.(*ExtendedError).Code).Should(gomega.Equal(Internal))
+	})
+	ginkgo.It("always keeps the code and message of the top-level error", func() {
+		SetConfig(Config{MaxDetailsSize: 10, FrameDetails: true})
+		err := NewResourceExhaustedErrorFrom(longChain(5), "quota exceeded %s", strings.Repeat("q", 100))
+		converted := status.Convert(err.ToGRPC())
+		gomega.Expect(converted.Details()).Should(gomega.BeEmpty())
+		received := FromGRPC(converted.Err())
+		gomega.Expect(received.Code).Should(gomega.Equal(ResourceExhausted))
+		gomega.Expect(received.Msg).Should(gomega.Equal(err.Msg))
+		gomega.Expect(received.From).Should(gomega.BeNil())
+	})
+	ginkgo.It("drops the error frames when the fields do not fit", func() {
+		SetConfig(Config{MaxDetailsSize: 512, FrameDetails: true})
+		err := NewInvalidArgumentErrorFrom(NewNotFoundError("not found"), "invalid app").
+			WithField("manifest", strings.Repeat("x", 1024)).WithRetryAfter(time.Second)
+		converted := status.Convert(err.ToGRPC())
+		gomega.Expect(statusSize(converted)).Should(gomega.BeNumerically("<=", 512))
+		gomega.Expect(converted.Details()).Should(gomega.HaveLen(1))
+		received := FromGRPC(converted.Err())
+		gomega.Expect(received.Code).Should(gomega.Equal(InvalidArgument))
+		gomega.Expect(received.Msg).Should(gomega.Equal("invalid app"))
+		gomega.Expect(received.RetryAfter()).Should(gomega.Equal(time.Second))
+
+		SetConfig(Config{MaxDetailsSize: 10, FrameDetails: true})
+		converted = status.Convert(err.ToGRPC())
+		gomega.Expect(converted.Details()).Should(gomega.BeEmpty())
+		gomega.Expect(FromGRPC(converted.Err()).Msg).Should(gomega.Equal("invalid app"))
+	})
+	ginkgo.It("does not limit the size by default", func() {
+		SetConfig(Config{FrameDetails: true})
+		err := longChain(30)
+		gomega.Expect(FromGRPC(err.ToGRPC())).Should(gomega.Equal(err))
+	})
+	ginkgo.It("keeps the structure of aggregated errors when dropping links", func() {
+		// 0: top -> list of [1, 4]; 1: a -> 2; 2: b -> 3; 3: c; 4: d
+		details := []*nerrorspb.ErrorFrame{
+			{Code: nerrorspb.ErrorCode_INTERNAL, Message: "top", CauseIndex: -1, CauseIndices: []int32{1, 4}},
+			{Code: nerrorspb.ErrorCode_ABORTED, Message: "a", CauseIndex: 2},
+			{Code: nerrorspb.ErrorCode_ABORTED, Message: "b", CauseIndex: 3},
+			{Code: nerrorspb.ErrorCode_NOT_FOUND, Message: "c", CauseIndex: -1},
+			{Code: nerrorspb.ErrorCode_INVALID_ARGUMENT, Message: "d", CauseIndex: -1},
+		}
+		reduced := dropLinks(details, 2)
+		gomega.Expect(reduced).Should(gomega.HaveLen(4))
+		gomega.Expect(reduced[0].CauseIndices).Should(gomega.Equal([]int32{1, 3}))
+		gomega.Expect(reduced[1].Message).Should(gomega.Equal("... 2 errors truncated"))
+		gomega.Expect(reduced[1].CauseIndex).Should(gomega.Equal(int32(2)))
+		gomega.Expect(reduced[2].Message).Should(gomega.Equal("c"))
+		gomega.Expect(reduced[3].Message).Should(gomega.Equal("d"))
+		gomega.Expect(details[0].CauseIndices).Should(gomega.Equal([]int32{1, 4}))
+
+		restored := ExtendedErrorFromDetail([]interface{}{reduced[0], reduced[1], reduced[2], reduced[3]})
+		list := restored.From.(*ErrorList)
+		gomega.Expect(list.Errors).Should(gomega.HaveLen(2))
+		gomega.Expect(list.Errors[0].(*ExtendedError).From.(*ExtendedError).Msg).Should(gomega.Equal("c"))
+		gomega.Expect(list.Errors[1].(*ExtendedError).Msg).Should(gomega.Equal("d"))
+	})
+})
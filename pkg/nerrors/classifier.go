@@ -0,0 +1,88 @@
+package nerrors
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Classifier returns the code of an error, and whether the error was recognised.
+type Classifier func(err error) (ErrorCode, bool)
+
+// SentinelClassifier returns a classifier that recognises the errors matching target with errors.Is.
+func SentinelClassifier(target error, code ErrorCode) Classifier {
+	return func(err error) (ErrorCode, bool) {
+		if errors.Is(err, target) {
+			return code, true
+		}
+		return Unknown, false
+	}
+}
+
+// builtinClassifiers with the classifiers of the standard library errors. They are consulted after the registered ones.
+var builtinClassifiers = []Classifier{
+	SentinelClassifier(os.ErrNotExist, NotFound),
+	SentinelClassifier(os.ErrExist, AlreadyExists),
+	SentinelClassifier(os.ErrPermission, PermissionDenied),
+	SentinelClassifier(os.ErrDeadlineExceeded, DeadlineExceeded),
+	SentinelClassifier(os.ErrClosed, FailedPrecondition),
+	SentinelClassifier(net.ErrClosed, Unavailable),
+	SentinelClassifier(io.ErrUnexpectedEOF, DataLoss),
+	SentinelClassifier(io.ErrShortWrite, DataLoss),
+	SentinelClassifier(io.EOF, OutOfRange),
+	SentinelClassifier(strconv.ErrSyntax, InvalidArgument),
+	SentinelClassifier(strconv.ErrRange, OutOfRange),
+}
+
+// classifiers with the registered classifiers in registration order.
+var classifiers = struct {
+	sync.RWMutex
+	list []Classifier
+}{}
+
+// RegisterClassifier adds a classifier to be consulted by FromError for the errors that are not recognised by the
+// library. Classifiers are consulted in registration order, and before the built-in ones for the standard library
+// errors, so they can override them.
+func RegisterClassifier(classifier Classifier) {
+	classifiers.Lock()
+	defer classifiers.Unlock()
+	classifiers.list = append(classifiers.list, classifier)
+}
+
+// OverrideClassifiers replaces the registered classifiers with the given ones, and returns a function that restores
+// the previous ones. It is intended for tests and scoped overrides:
+//
+//	defer nerrors.OverrideClassifiers(myClassifier)()
+func OverrideClassifiers(override ...Classifier) func() {
+	classifiers.Lock()
+	defer classifiers.Unlock()
+	previous := classifiers.list
+	classifiers.list = append([]Classifier(nil), override...)
+	return func() {
+		classifiers.Lock()
+		defer classifiers.Unlock()
+		classifiers.list = previous
+	}
+}
+
+// classifyWithRegistry consults the registered classifiers followed by the built-in ones.
+func classifyWithRegistry(err error) (ErrorCode, bool) {
+	classifiers.RLock()
+	registered := classifiers.list
+	classifiers.RUnlock()
+
+	for _, classifier := range registered {
+		if code, ok := classifier(err); ok {
+			return code, true
+		}
+	}
+	for _, classifier := range builtinClassifiers {
+		if code, ok := classifier(err); ok {
+			return code, true
+		}
+	}
+	return Unknown, false
+}
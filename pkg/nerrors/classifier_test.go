@@ -0,0 +1,48 @@
+package nerrors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Handler test on error classifiers", func() {
+	driverErr := fmt.Errorf("driver: connection refused")
+	driverClassifier := func(err error) (ErrorCode, bool) {
+		if strings.HasPrefix(err.Error(), "driver:") {
+			return Unavailable, true
+		}
+		return Unknown, false
+	}
+
+	ginkgo.It("classifies the standard library errors", func() {
+		_, err := os.Open("/this/file/does/not/exist")
+		gomega.Expect(FromError(err).Code).Should(gomega.Equal(NotFound))
+		gomega.Expect(FromError(err).From).Should(gomega.Equal(err))
+		gomega.Expect(FromError(fmt.Errorf("reading: %w", io.ErrUnexpectedEOF)).Code).Should(gomega.Equal(DataLoss))
+		gomega.Expect(FromError(os.ErrPermission).Code).Should(gomega.Equal(PermissionDenied))
+		gomega.Expect(FromError(os.ErrExist).Code).Should(gomega.Equal(AlreadyExists))
+	})
+	ginkgo.It("consults the registered classifiers", func() {
+		defer OverrideClassifiers()()
+		gomega.Expect(FromError(driverErr).Code).Should(gomega.Equal(Unknown))
+		RegisterClassifier(driverClassifier)
+		gomega.Expect(FromError(driverErr).Code).Should(gomega.Equal(Unavailable))
+		gomega.Expect(FromError(driverErr).From).Should(gomega.Equal(driverErr))
+	})
+	ginkgo.It("consults the classifiers in registration order before the built-in ones", func() {
+		defer OverrideClassifiers(SentinelClassifier(os.ErrNotExist, Internal))()
+		RegisterClassifier(SentinelClassifier(os.ErrNotExist, FailedPrecondition))
+		gomega.Expect(FromError(os.ErrNotExist).Code).Should(gomega.Equal(Internal))
+	})
+	ginkgo.It("restores the previous classifiers", func() {
+		restore := OverrideClassifiers(driverClassifier)
+		gomega.Expect(FromError(driverErr).Code).Should(gomega.Equal(Unavailable))
+		restore()
+		gomega.Expect(FromError(driverErr).Code).Should(gomega.Equal(Unknown))
+	})
+})
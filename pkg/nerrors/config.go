@@ -0,0 +1,248 @@
+package nerrors
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultStackDepth is the maximum number of frames captured when no depth is configured.
+const DefaultStackDepth = 32
+
+// Environment variables read when the package is loaded to configure the capture of the stack traces.
+const (
+	// EnvStackDepth with the maximum number of frames captured.
+	EnvStackDepth = "NERRORS_STACK_DEPTH"
+	// EnvStackSkip with the number of extra frames to skip.
+	EnvStackSkip = "NERRORS_STACK_SKIP"
+	// EnvStackDisabled set to true disables the capture of the stack traces.
+	EnvStackDisabled = "NERRORS_STACK_DISABLED"
+	// EnvStackDisabledCodes with a comma separated list of the codes whose errors are created without stack trace
+	// (e.g., "NotFound,InvalidArgument").
+	EnvStackDisabledCodes = "NERRORS_STACK_DISABLED_CODES"
+	// EnvStackSampleRate with the fraction of errors whose stack trace is captured (e.g., 0.1).
+	EnvStackSampleRate = "NERRORS_STACK_SAMPLE_RATE"
+	// EnvStackDropPackages with a comma separated list of the package prefixes whose frames are removed from the stack
+	// traces (e.g., "runtime,github.com/onsi/ginkgo").
+	EnvStackDropPackages = "NERRORS_STACK_DROP_PACKAGES"
+	// EnvStackCollapsePackages with a comma separated list of the package prefixes whose consecutive frames are
+	// collapsed (e.g., "google.golang.org/grpc").
+	EnvStackCollapsePackages = "NERRORS_STACK_COLLAPSE_PACKAGES"
+	// EnvStackTrimPaths set to true trims the build paths from the file names of the stack traces.
+	EnvStackTrimPaths = "NERRORS_STACK_TRIM_PATHS"
+	// EnvStackLazy set to true resolves the stack traces only when they are needed, leaving the StackTrace field of
+	// the errors empty.
+	EnvStackLazy = "NERRORS_STACK_LAZY"
+	// EnvGRPCMaxDetailsSize with the maximum size in bytes of the gRPC status details (e.g., 4096).
+	EnvGRPCMaxDetailsSize = "NERRORS_GRPC_MAX_DETAILS_SIZE"
+	// EnvGRPCFrameDetails set to true sends the gRPC details as error frames instead of the format of the older
+	// versions of the library.
+	EnvGRPCFrameDetails = "NERRORS_GRPC_FRAME_DETAILS"
+)
+
+// Config with the configuration of the capture of the stack traces and of their encoding. The zero value captures
+// the stack trace of every error with the default depth.
+type Config struct {
+	// MaxDepth with the maximum number of frames captured. Zero means DefaultStackDepth.
+	MaxDepth int
+	// SkipFrames with the number of extra frames to skip, so the helpers wrapping the creation of the errors are
+	// not part of the stack trace.
+	SkipFrames int
+	// Disabled turns off the capture of the stack traces.
+	Disabled bool
+	// DisabledCodes with the codes whose errors are created without stack trace (e.g., NotFound on hot paths).
+	DisabledCodes []ErrorCode
+	// SampleRate with the fraction of errors whose stack trace is captured, between 0 and 1. Zero captures the
+	// stack trace of every error, use Disabled to turn the capture off.
+	SampleRate float64
+	// DropPackages with the package prefixes whose frames are removed from the stack traces (e.g., "runtime").
+	DropPackages []string
+	// CollapsePackages with the package prefixes of the libraries whose consecutive frames are collapsed into the
+	// first one (e.g., "google.golang.org/grpc").
+	CollapsePackages []string
+	// TrimPaths trims GOROOT, GOPATH and the root of the main module from the file names of the stack traces.
+	TrimPaths bool
+	// LazyStackTrace resolves the stack traces only when they are needed (Frames, StackEntries, StackTraceToString or
+	// the encodings), instead of when the errors are created. It saves the resolution on the hot paths whose errors
+	// are not printed, but the StackTrace field of the errors is left empty.
+	LazyStackTrace bool
+	// MaxDetailsSize with the maximum size in bytes of the gRPC status sent in the grpc-status-details-bin trailer,
+	// before its base64 encoding. Zero or a negative value means no limit. Note that proxies usually limit the whole
+	// metadata to 8 KB, and the base64 encoding adds a third to the size.
+	MaxDetailsSize int
+	// FrameDetails sends the error chain as error frames, with the fields, the retry delay and the attached details.
+	// Otherwise the chain is sent as the ErrorDetails of the older versions of the library, which cannot decode
+	// anything else, and MaxDetailsSize is not applied. Set it once every client is upgraded.
+	FrameDetails bool
+}
+
+// clone returns a copy of the configuration that does not share the slices.
+func (c Config) clone() Config {
+	c.DisabledCodes = append([]ErrorCode(nil), c.DisabledCodes...)
+	c.DropPackages = append([]string(nil), c.DropPackages...)
+	c.CollapsePackages = append([]string(nil), c.CollapsePackages...)
+	return c
+}
+
+// activeConfig with the configuration in use. It is replaced as a whole so it can be read without locks.
+type activeConfig struct {
+	Config
+	disabledCodes map[ErrorCode]bool
+}
+
+// captures checks if the stack trace of an error with the given code must be captured.
+func (ac *activeConfig) captures(code ErrorCode) bool {
+	if ac.Disabled || ac.disabledCodes[code] {
+		return false
+	}
+	if ac.SampleRate > 0 && ac.SampleRate < 1 {
+		return rand.Float64() < ac.SampleRate
+	}
+	return true
+}
+
+// depth returns the maximum number of frames to capture.
+func (ac *activeConfig) depth() int {
+	if ac.MaxDepth > 0 {
+		return ac.MaxDepth
+	}
+	return DefaultStackDepth
+}
+
+var config atomic.Value
+
+func init() {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		fmt.Printf("Error loading the nerrors configuration: %s\n", err.Error())
+	}
+	SetConfig(cfg)
+}
+
+// SetConfig replaces the configuration of the capture of the stack traces. It is safe to call it concurrently with
+// the creation of errors.
+func SetConfig(cfg Config) {
+	active := &activeConfig{Config: cfg.clone(), disabledCodes: make(map[ErrorCode]bool, len(cfg.DisabledCodes))}
+	for _, code := range cfg.DisabledCodes {
+		active.disabledCodes[code] = true
+	}
+	config.Store(active)
+}
+
+// GetConfig returns the configuration of the capture of the stack traces.
+func GetConfig() Config {
+	return loadConfig().Config.clone()
+}
+
+// loadConfig returns the configuration in use.
+func loadConfig() *activeConfig {
+	return config.Load().(*activeConfig)
+}
+
+// ConfigFromEnv creates a configuration from the environment variables. Invalid variables are reported in the
+// returned error, and ignored in the returned configuration.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{}
+	invalid := make([]string, 0)
+
+	if value, exists := os.LookupEnv(EnvStackDepth); exists {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			invalid = append(invalid, EnvStackDepth)
+		} else {
+			cfg.MaxDepth = depth
+		}
+	}
+	if value, exists := os.LookupEnv(EnvStackSkip); exists {
+		skip, err := strconv.Atoi(value)
+		if err != nil || skip < 0 {
+			invalid = append(invalid, EnvStackSkip)
+		} else {
+			cfg.SkipFrames = skip
+		}
+	}
+	if value, exists := os.LookupEnv(EnvStackDisabled); exists {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			invalid = append(invalid, EnvStackDisabled)
+		} else {
+			cfg.Disabled = disabled
+		}
+	}
+	if value, exists := os.LookupEnv(EnvStackDisabledCodes); exists {
+		for _, name := range splitList(value) {
+			code, known := FromStringCode[name]
+			if !known {
+				invalid = append(invalid, EnvStackDisabledCodes)
+				continue
+			}
+			cfg.DisabledCodes = append(cfg.DisabledCodes, code)
+		}
+	}
+	if value, exists := os.LookupEnv(EnvStackSampleRate); exists {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			invalid = append(invalid, EnvStackSampleRate)
+		} else {
+			cfg.SampleRate = rate
+		}
+	}
+	if value, exists := os.LookupEnv(EnvStackDropPackages); exists {
+		cfg.DropPackages = splitList(value)
+	}
+	if value, exists := os.LookupEnv(EnvStackCollapsePackages); exists {
+		cfg.CollapsePackages = splitList(value)
+	}
+	if value, exists := os.LookupEnv(EnvStackTrimPaths); exists {
+		trim, err := strconv.ParseBool(value)
+		if err != nil {
+			invalid = append(invalid, EnvStackTrimPaths)
+		} else {
+			cfg.TrimPaths = trim
+		}
+	}
+	if value, exists := os.LookupEnv(EnvStackLazy); exists {
+		lazy, err := strconv.ParseBool(value)
+		if err != nil {
+			invalid = append(invalid, EnvStackLazy)
+		} else {
+			cfg.LazyStackTrace = lazy
+		}
+	}
+	if value, exists := os.LookupEnv(EnvGRPCMaxDetailsSize); exists {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			invalid = append(invalid, EnvGRPCMaxDetailsSize)
+		} else {
+			cfg.MaxDetailsSize = size
+		}
+	}
+	if value, exists := os.LookupEnv(EnvGRPCFrameDetails); exists {
+		frames, err := strconv.ParseBool(value)
+		if err != nil {
+			invalid = append(invalid, EnvGRPCFrameDetails)
+		} else {
+			cfg.FrameDetails = frames
+		}
+	}
+
+	if len(invalid) > 0 {
+		return cfg, fmt.Errorf("invalid environment variables: %s", strings.Join(invalid, ", "))
+	}
+	return cfg, nil
+}
+
+// splitList splits a comma separated list, discarding the empty elements.
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		if element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
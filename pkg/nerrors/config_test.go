@@ -0,0 +1,140 @@
+package nerrors
+
+import (
+	"os"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// newWrappedError is a helper wrapping the creation of an error, used to check the skipped frames.
+func newWrappedError() *ExtendedError {
+	return NewInternalError("wrapped")
+}
+
+var _ = ginkgo.Describe("Handler test on config", func() {
+	var previous Config
+
+	ginkgo.BeforeEach(func() {
+		previous = GetConfig()
+	})
+	ginkgo.AfterEach(func() {
+		SetConfig(previous)
+	})
+
+	ginkgo.It("captures the stack trace with the default config", func() {
+		SetConfig(Config{})
+		gomega.Expect(NewNotFoundError("not found").StackEntries()).ShouldNot(gomega.BeEmpty())
+	})
+	ginkgo.It("limits the depth of the stack trace", func() {
+		SetConfig(Config{MaxDepth: 2})
+		gomega.Expect(NewNotFoundError("not found").StackEntries()).Should(gomega.HaveLen(2))
+	})
+	ginkgo.It("skips extra frames", func() {
+		SetConfig(Config{SkipFrames: 2})
+		entries := newWrappedError().StackEntries()
+		gomega.Expect(entries).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(entries[0]).Should(gomega.ContainSubstring("nerrors.newWrappedError\n"))
+	})
+	ginkgo.It("disables the stack trace", func() {
+		SetConfig(Config{Disabled: true})
+		err := NewNotFoundError("not found")
+		gomega.Expect(err.StackEntries()).Should(gomega.BeNil())
+		gomega.Expect(err.StackTraceToString()).Should(gomega.Equal("[NotFound] not found\n"))
+	})
+	ginkgo.It("disables the stack trace per code", func() {
+		SetConfig(Config{DisabledCodes: []ErrorCode{NotFound}})
+		gomega.Expect(NewNotFoundError("not found").StackEntries()).Should(gomega.BeNil())
+		gomega.Expect(NewInternalError("internal").StackEntries()).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(FromError(os.ErrNotExist).StackEntries()).Should(gomega.BeNil())
+	})
+	ginkgo.It("samples the stack traces", func() {
+		SetConfig(Config{SampleRate: 0.5})
+		captured := 0
+		for i := 0; i < 1000; i++ {
+			if NewNotFoundError("not found").StackEntries() != nil {
+				captured++
+			}
+		}
+		gomega.Expect(captured).Should(gomega.BeNumerically(">", 0))
+		gomega.Expect(captured).Should(gomega.BeNumerically("<", 1000))
+	})
+	ginkgo.It("returns a copy of the config", func() {
+		codes := []ErrorCode{NotFound}
+		SetConfig(Config{DisabledCodes: codes})
+		codes[0] = Internal
+		cfg := GetConfig()
+		gomega.Expect(cfg.DisabledCodes).Should(gomega.Equal([]ErrorCode{NotFound}))
+		cfg.DisabledCodes[0] = Internal
+		gomega.Expect(GetConfig().DisabledCodes).Should(gomega.Equal([]ErrorCode{NotFound}))
+	})
+	ginkgo.It("changes the config concurrently", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				SetConfig(Config{MaxDepth: i + 1})
+				_ = NewNotFoundError("not found").StackEntries()
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	ginkgo.Context("loading the environment", func() {
+		setEnv := func(values map[string]string) {
+			for key, value := range values {
+				gomega.Expect(os.Setenv(key, value)).To(gomega.Succeed())
+			}
+		}
+		ginkgo.AfterEach(func() {
+			for _, key := range []string{EnvStackDepth, EnvStackSkip, EnvStackDisabled, EnvStackDisabledCodes,
+				EnvStackSampleRate, EnvStackDropPackages, EnvStackCollapsePackages, EnvStackTrimPaths, EnvStackLazy,
+				EnvGRPCMaxDetailsSize, EnvGRPCFrameDetails} {
+				_ = os.Unsetenv(key)
+			}
+		})
+		ginkgo.It("reads the config from the environment", func() {
+			setEnv(map[string]string{
+				EnvStackDepth:            "10",
+				EnvStackSkip:             "1",
+				EnvStackDisabled:         "false",
+				EnvStackDisabledCodes:    "NotFound, InvalidArgument",
+				EnvStackSampleRate:       "0.25",
+				EnvStackDropPackages:     "runtime,github.com/onsi/ginkgo",
+				EnvStackCollapsePackages: "google.golang.org/grpc",
+				EnvStackTrimPaths:        "true",
+				EnvStackLazy:             "true",
+				EnvGRPCMaxDetailsSize:    "4096",
+				EnvGRPCFrameDetails:      "true",
+			})
+			cfg, err := ConfigFromEnv()
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(cfg).Should(gomega.Equal(Config{
+				MaxDepth:         10,
+				SkipFrames:       1,
+				DisabledCodes:    []ErrorCode{NotFound, InvalidArgument},
+				SampleRate:       0.25,
+				DropPackages:     []string{"runtime", "github.com/onsi/ginkgo"},
+				CollapsePackages: []string{"google.golang.org/grpc"},
+				TrimPaths:        true,
+				LazyStackTrace:   true,
+				MaxDetailsSize:   4096,
+				FrameDetails:     true,
+			}))
+		})
+		ginkgo.It("reports the invalid variables", func() {
+			setEnv(map[string]string{
+				EnvStackDepth:         "deep",
+				EnvStackDisabledCodes: "NotFound,Missing",
+				EnvStackSampleRate:    "2",
+			})
+			cfg, err := ConfigFromEnv()
+			gomega.Expect(err).ShouldNot(gomega.Succeed())
+			gomega.Expect(err.Error()).Should(gomega.ContainSubstring(EnvStackDepth))
+			gomega.Expect(err.Error()).Should(gomega.ContainSubstring(EnvStackSampleRate))
+			gomega.Expect(cfg).Should(gomega.Equal(Config{DisabledCodes: []ErrorCode{NotFound}}))
+		})
+	})
+})
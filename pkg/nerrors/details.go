@@ -0,0 +1,129 @@
+package nerrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/napptive/grpc-common-go"
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// WithDetails attaches protobuf messages to the error, such as the standard google.rpc error details
+// (errdetails.BadRequest, errdetails.PreconditionFailure, errdetails.QuotaFailure, errdetails.ResourceInfo or
+// errdetails.ErrorInfo). They are sent as details of the gRPC status after the error frames. The details that cannot
+// be encoded are reported when the error is converted into a gRPC status. It returns the error to allow chaining calls
+// when the error is created:
+//
+//	return nerrors.NewInvalidArgumentError("invalid app").WithDetails(&errdetails.BadRequest{...})
+func (ee *ExtendedError) WithDetails(details ...proto.Message) *ExtendedError {
+	for _, detail := range details {
+		encoded, err := anypb.New(detail)
+		if err != nil {
+			ee.detailsErr = fmt.Errorf("cannot attach the detail %T: %w", detail, err)
+			continue
+		}
+		ee.details = append(ee.details, encoded)
+	}
+	return ee
+}
+
+// Details returns the protobuf details attached to the errors of the chain, from the outermost to the innermost. The
+// details received through gRPC are returned verbatim, including the ones of types unknown to this library.
+func (ee *ExtendedError) Details() []*anypb.Any {
+	var details []*anypb.Any
+	var current error = ee
+	for current != nil {
+		if extended, ok := current.(*ExtendedError); ok {
+			details = append(details, extended.details...)
+		} else if se, ok := current.(grpcStatus); ok {
+			details = append(details, extraDetails(se.GRPCStatus())...)
+		}
+		current = errors.Unwrap(current)
+	}
+	return details
+}
+
+// detailsError returns the first error found attaching the details of the chain, if any.
+func (ee *ExtendedError) detailsError() error {
+	var current error = ee
+	for current != nil {
+		if extended, ok := current.(*ExtendedError); ok && extended.detailsErr != nil {
+			return extended.detailsErr
+		}
+		current = errors.Unwrap(current)
+	}
+	return nil
+}
+
+// BadRequest returns the google.rpc.BadRequest detail of the chain, or nil if there is none.
+func (ee *ExtendedError) BadRequest() *errdetails.BadRequest {
+	detail := &errdetails.BadRequest{}
+	if ee.detail(detail) {
+		return detail
+	}
+	return nil
+}
+
+// PreconditionFailure returns the google.rpc.PreconditionFailure detail of the chain, or nil if there is none.
+func (ee *ExtendedError) PreconditionFailure() *errdetails.PreconditionFailure {
+	detail := &errdetails.PreconditionFailure{}
+	if ee.detail(detail) {
+		return detail
+	}
+	return nil
+}
+
+// QuotaFailure returns the google.rpc.QuotaFailure detail of the chain, or nil if there is none.
+func (ee *ExtendedError) QuotaFailure() *errdetails.QuotaFailure {
+	detail := &errdetails.QuotaFailure{}
+	if ee.detail(detail) {
+		return detail
+	}
+	return nil
+}
+
+// ResourceInfo returns the google.rpc.ResourceInfo detail of the chain, or nil if there is none.
+func (ee *ExtendedError) ResourceInfo() *errdetails.ResourceInfo {
+	detail := &errdetails.ResourceInfo{}
+	if ee.detail(detail) {
+		return detail
+	}
+	return nil
+}
+
+// ErrorInfo returns the google.rpc.ErrorInfo detail of the chain, or nil if there is none.
+func (ee *ExtendedError) ErrorInfo() *errdetails.ErrorInfo {
+	detail := &errdetails.ErrorInfo{}
+	if ee.detail(detail) {
+		return detail
+	}
+	return nil
+}
+
+// detail decodes into target the first detail of the chain with the same type. It returns false if there is none.
+func (ee *ExtendedError) detail(target proto.Message) bool {
+	for _, detail := range ee.Details() {
+		if detail.MessageIs(target) {
+			return detail.UnmarshalTo(target) == nil
+		}
+	}
+	return false
+}
+
+// extraDetails returns the details of a gRPC status that are not decoded by this library: the error frames, the
+// ErrorDetails of older versions and the RetryInfo are skipped.
+func extraDetails(st *status.Status) []*anypb.Any {
+	var details []*anypb.Any
+	for _, detail := range st.Proto().GetDetails() {
+		if detail.MessageIs(&nerrorspb.ErrorFrame{}) || detail.MessageIs(&grpc_common_go.ErrorDetails{}) ||
+			detail.MessageIs(&errdetails.RetryInfo{}) {
+			continue
+		}
+		details = append(details, detail)
+	}
+	return details
+}
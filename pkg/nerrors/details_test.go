@@ -0,0 +1,113 @@
+package nerrors
+
+import (
+	"github.com/napptive/grpc-common-go"
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var _ = ginkgo.Describe("Handler test on google.rpc details", func() {
+	badRequest := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "replicas", Description: "must be positive"},
+	}}
+	errorInfo := &errdetails.ErrorInfo{Reason: "QUOTA_EXCEEDED", Domain: "napptive.com", Metadata: map[string]string{"quota": "apps"}}
+	unknown := &anypb.Any{TypeUrl: "type.googleapis.com/acme.v1.Unknown", Value: []byte{0x0a, 0x03, 'a', 'b', 'c'}}
+	var previous Config
+
+	ginkgo.BeforeEach(func() {
+		previous = GetConfig()
+		SetConfig(Config{FrameDetails: true})
+	})
+	ginkgo.AfterEach(func() {
+		SetConfig(previous)
+	})
+
+	ginkgo.It("reads the attached details", func() {
+		err := NewInvalidArgumentError("invalid app").WithDetails(badRequest, errorInfo)
+		gomega.Expect(proto.Equal(err.BadRequest(), badRequest)).Should(gomega.BeTrue())
+		gomega.Expect(proto.Equal(err.ErrorInfo(), errorInfo)).Should(gomega.BeTrue())
+		gomega.Expect(err.PreconditionFailure()).Should(gomega.BeNil())
+		gomega.Expect(err.QuotaFailure()).Should(gomega.BeNil())
+		gomega.Expect(err.ResourceInfo()).Should(gomega.BeNil())
+		gomega.Expect(proto.Equal(NewInternalErrorFrom(err, "cannot deploy").BadRequest(), badRequest)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("sends the details after the error frames", func() {
+		err := NewFailedPreconditionErrorFrom(NewNotFoundError("namespace not found"), "cannot deploy").WithDetails(
+			&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{{Type: "NAMESPACE", Subject: "apps"}}},
+			&errdetails.ResourceInfo{ResourceType: "namespace", ResourceName: "apps"})
+		details := status.Convert(err.ToGRPC()).Details()
+		gomega.Expect(details).Should(gomega.HaveLen(4))
+		gomega.Expect(details[0]).Should(gomega.BeAssignableToTypeOf(&nerrorspb.ErrorFrame{}))
+		gomega.Expect(details[1]).Should(gomega.BeAssignableToTypeOf(&nerrorspb.ErrorFrame{}))
+		gomega.Expect(details[2]).Should(gomega.BeAssignableToTypeOf(&errdetails.PreconditionFailure{}))
+		gomega.Expect(details[3]).Should(gomega.BeAssignableToTypeOf(&errdetails.ResourceInfo{}))
+		gomega.Expect(ExtendedErrorFromDetail(details).From.(*ExtendedError).Code).Should(gomega.Equal(NotFound))
+	})
+	ginkgo.It("restores the details", func() {
+		err := NewResourceExhaustedError("quota exceeded").WithDetails(
+			&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: "apps", Description: "10 apps"}}})
+		received := FromGRPC(err.ToGRPC())
+		gomega.Expect(received.Msg).Should(gomega.Equal("quota exceeded"))
+		gomega.Expect(received.QuotaFailure().Violations[0].Subject).Should(gomega.Equal("apps"))
+		gomega.Expect(received.Details()).Should(gomega.HaveLen(1))
+	})
+	ginkgo.It("converts the statuses with details of other services", func() {
+		st, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(badRequest, errorInfo)
+		gomega.Expect(err).Should(gomega.Succeed())
+		received := FromGRPC(st.Err())
+		gomega.Expect(received.Code).Should(gomega.Equal(InvalidArgument))
+		gomega.Expect(received.Msg).Should(gomega.Equal("invalid request"))
+		gomega.Expect(proto.Equal(received.BadRequest(), badRequest)).Should(gomega.BeTrue())
+		gomega.Expect(proto.Equal(received.ErrorInfo(), errorInfo)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("preserves the unknown details verbatim", func() {
+		st := status.FromProto(&spb.Status{Code: int32(codes.Aborted), Message: "conflict", Details: []*anypb.Any{unknown}})
+		received := FromGRPC(st.Err())
+		gomega.Expect(received.Code).Should(gomega.Equal(Aborted))
+		gomega.Expect(received.Details()).Should(gomega.HaveLen(1))
+		gomega.Expect(proto.Equal(received.Details()[0], unknown)).Should(gomega.BeTrue())
+
+		forwarded := status.Convert(NewInternalErrorFrom(received, "cannot deploy").ToGRPC()).Proto().Details
+		gomega.Expect(proto.Equal(forwarded[len(forwarded)-1], unknown)).Should(gomega.BeTrue())
+		restored := FromGRPC(status.FromProto(&spb.Status{Code: int32(codes.Internal), Details: forwarded}).Err())
+		gomega.Expect(proto.Equal(restored.Details()[0], unknown)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("forwards the details of the wrapped status errors", func() {
+		st, err := status.New(codes.InvalidArgument, "invalid request").WithDetails(badRequest)
+		gomega.Expect(err).Should(gomega.Succeed())
+		wrapped := NewInternalErrorFrom(st.Err(), "cannot deploy")
+		gomega.Expect(proto.Equal(wrapped.BadRequest(), badRequest)).Should(gomega.BeTrue())
+		gomega.Expect(proto.Equal(FromGRPC(wrapped.ToGRPC()).BadRequest(), badRequest)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("converts the details of older versions followed by other details", func() {
+		st, err := status.New(codes.Internal, "internal error").WithDetails(
+			&grpc_common_go.ErrorDetails{Detail: "Code: Internal - Msg: internal error"}, errorInfo)
+		gomega.Expect(err).Should(gomega.Succeed())
+		received := FromGRPC(st.Err())
+		gomega.Expect(received.Msg).Should(gomega.Equal("internal error"))
+		gomega.Expect(proto.Equal(received.ErrorInfo(), errorInfo)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("reports the details that cannot be encoded", func() {
+		err := NewInternalErrorFrom(NewInvalidArgumentError("invalid app").WithDetails(&errdetails.ErrorInfo{Reason: "\xff"}), "cannot deploy")
+		gomega.Expect(err.Details()).Should(gomega.BeEmpty())
+		_, stErr := err.grpcStatus()
+		gomega.Expect(stErr).Should(gomega.MatchError(gomega.ContainSubstring("cannot attach the detail *errdetails.ErrorInfo")))
+		gomega.Expect(err.GRPCStatus().Code()).Should(gomega.Equal(codes.Internal))
+		gomega.Expect(err.GRPCStatus().Details()).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("strips the details at the boundaries unless requested", func() {
+		var incidents []loggedIncident
+		boundary := testBoundary(&incidents)
+		err := NewInvalidArgumentError("invalid app").WithDetails(badRequest)
+		gomega.Expect(boundary.Sanitize(err).Details()).Should(gomega.BeEmpty())
+		boundary.KeepDetails = true
+		gomega.Expect(proto.Equal(boundary.Sanitize(err).BadRequest(), badRequest)).Should(gomega.BeTrue())
+	})
+})
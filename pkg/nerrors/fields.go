@@ -0,0 +1,99 @@
+package nerrors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// WithField attaches a structured key/value field to the error. The value should be representable in JSON, as the
+// fields received through gRPC or JSON are decoded with the JSON types (e.g. numbers as float64). It returns the error
+// to allow chaining calls when the error is created:
+//
+//	return nerrors.NewNotFoundError("app not found").WithField("app_id", appID)
+func (ee *ExtendedError) WithField(key string, value interface{}) *ExtendedError {
+	if ee.fields == nil {
+		ee.fields = make(map[string]interface{})
+	}
+	ee.fields[key] = value
+	return ee
+}
+
+// WithFields attaches several structured key/value fields to the error. It returns the error to allow chaining calls.
+func (ee *ExtendedError) WithFields(fields map[string]interface{}) *ExtendedError {
+	for key, value := range fields {
+		ee.WithField(key, value)
+	}
+	return ee
+}
+
+// Fields returns the fields attached to the errors of the chain. Fields of outer errors override the ones of the
+// errors that caused them.
+func (ee *ExtendedError) Fields() map[string]interface{} {
+	chain := make([]*ExtendedError, 0)
+	var current error = ee
+	for current != nil {
+		if extended, ok := current.(*ExtendedError); ok {
+			chain = append(chain, extended)
+		}
+		current = errors.Unwrap(current)
+	}
+
+	fields := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, value := range chain[i].fields {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// formatFields returns the fields of the error sorted by key with the format " {key=value, ...}", or an empty string
+// if the error has no fields.
+func formatFields(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]string, len(keys))
+	for i, key := range keys {
+		entries[i] = fmt.Sprintf("%s=%v", key, fields[key])
+	}
+	return " {" + strings.Join(entries, ", ") + "}"
+}
+
+// toFieldValues converts the fields into protobuf values. Values without a protobuf representation are sent as
+// their textual representation.
+func toFieldValues(fields map[string]interface{}) map[string]*structpb.Value {
+	if len(fields) == 0 {
+		return nil
+	}
+	values := make(map[string]*structpb.Value, len(fields))
+	for key, field := range fields {
+		value, err := structpb.NewValue(field)
+		if err != nil {
+			value = structpb.NewStringValue(strings.ToValidUTF8(fmt.Sprint(field), "�"))
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// fromFieldValues converts protobuf values into fields.
+func fromFieldValues(values map[string]*structpb.Value) map[string]interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		fields[key] = value.AsInterface()
+	}
+	return fields
+}
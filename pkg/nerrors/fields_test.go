@@ -0,0 +1,72 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Handler test on error fields", func() {
+	ginkgo.It("attaches fields to the error", func() {
+		err := NewNotFoundError("app not found").WithField("app_id", "app-1").WithFields(map[string]interface{}{
+			"namespace": "default",
+			"retries":   3,
+		})
+		gomega.Expect(err.Fields()).Should(gomega.Equal(map[string]interface{}{
+			"app_id":    "app-1",
+			"namespace": "default",
+			"retries":   3,
+		}))
+		gomega.Expect(NewNotFoundError("app not found").Fields()).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("merges the fields of the chain", func() {
+		inner := NewNotFoundError("app not found").WithFields(map[string]interface{}{"app_id": "app-1", "namespace": "inner"})
+		wrapped := fmt.Errorf("loading: %w", inner)
+		err := NewInternalErrorFrom(wrapped, "internal error").WithField("namespace", "outer")
+		gomega.Expect(err.Fields()).Should(gomega.Equal(map[string]interface{}{
+			"app_id":    "app-1",
+			"namespace": "outer",
+		}))
+		gomega.Expect(inner.Fields()["namespace"]).Should(gomega.Equal("inner"))
+	})
+	ginkgo.It("includes the fields in the stack trace", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("app not found").WithField("app_id", "app-1"), "internal error").
+			WithFields(map[string]interface{}{"namespace": "default", "attempt": 2})
+		trace := err.StackTraceToString()
+		gomega.Expect(trace).Should(gomega.ContainSubstring("[Internal] internal error {attempt=2, namespace=default}\n"))
+		gomega.Expect(trace).Should(gomega.ContainSubstring("[NotFound] app not found {app_id=app-1}\n"))
+	})
+	ginkgo.It("carries the fields through gRPC", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{FrameDetails: true})
+		err := NewInternalErrorFrom(NewNotFoundError("app not found").WithField("app_id", "app-1"), "internal error").
+			WithFields(map[string]interface{}{"attempt": 2, "timeout": time.Second})
+		converted := FromGRPC(err.ToGRPC())
+		gomega.Expect(converted.Fields()).Should(gomega.Equal(map[string]interface{}{
+			"app_id":  "app-1",
+			"attempt": float64(2),
+			"timeout": "1s",
+		}))
+		gomega.Expect(FromError(converted.From).Fields()).Should(gomega.Equal(map[string]interface{}{"app_id": "app-1"}))
+	})
+	ginkgo.It("carries the fields through JSON", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("app not found").WithField("app_id", "app-1"), "internal error").
+			WithField("namespace", "default")
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal(raw, restored)).Should(gomega.Succeed())
+		gomega.Expect(restored).Should(gomega.Equal(err))
+	})
+	ginkgo.It("decodes the fields with the JSON types", func() {
+		raw, mErr := json.Marshal(NewInternalError("internal error").WithFields(map[string]interface{}{"attempt": 2, "retry": true}))
+		gomega.Expect(mErr).Should(gomega.Succeed())
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal(raw, restored)).Should(gomega.Succeed())
+		gomega.Expect(restored.Fields()).Should(gomega.Equal(map[string]interface{}{"attempt": float64(2), "retry": true}))
+	})
+})
@@ -0,0 +1,97 @@
+package nerrors
+
+import (
+	"path"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+// mainModule with the path of the main module, as read from the build information.
+var mainModule = readMainModule()
+
+// moduleCache with the directory of the module cache inside GOPATH, whose files are made relative to it.
+const moduleCache = "/pkg/mod/"
+
+// moduleRoot with the directory of the main module in the build machine. It is discovered from the frames of the
+// packages of the main module.
+var moduleRoot atomic.Value
+
+// readMainModule returns the path of the main module, or an empty string if it is not available.
+func readMainModule() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return info.Main.Path
+}
+
+// functionPackage returns the path of the package of a function as reported by the runtime, for example
+// "github.com/napptive/nerrors/pkg/nerrors" for "github.com/napptive/nerrors/pkg/nerrors.(*ExtendedError).Error".
+func functionPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return function
+	}
+	return function[:slash+1+dot]
+}
+
+// matchPackage returns the first prefix matching the package, or an empty string if none matches.
+func matchPackage(pkg string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(pkg, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// filterFrames removes the frames of the dropped packages, and collapses the consecutive frames of the same library
+// into the first one.
+func (ac *activeConfig) filterFrames(frames StackTrace) StackTrace {
+	if len(ac.DropPackages) == 0 && len(ac.CollapsePackages) == 0 {
+		return frames
+	}
+	filtered := make(StackTrace, 0, len(frames))
+	collapsing := ""
+	for _, frame := range frames {
+		if matchPackage(frame.Package, ac.DropPackages) != "" {
+			continue
+		}
+		library := matchPackage(frame.Package, ac.CollapsePackages)
+		if library == "" || library != collapsing {
+			filtered = append(filtered, frame)
+		}
+		collapsing = library
+	}
+	return filtered
+}
+
+// trimPath removes the build directories from the file name of a frame of the given package if the configuration
+// requires it. The directories are obtained from the file name itself, as the binary may run on a machine other than
+// the one that built it. The files of the module cache are made relative to it, the files of the main module relative
+// to its root, and the files of the other packages (GOROOT, GOPATH) start with the import path of their package.
+func (ac *activeConfig) trimPath(file string, pkg string) string {
+	if !ac.TrimPaths {
+		return file
+	}
+	if index := strings.LastIndex(file, moduleCache); index >= 0 {
+		return file[index+len(moduleCache):]
+	}
+	if mainModule != "" {
+		if pkg == mainModule || strings.HasPrefix(pkg, mainModule+"/") {
+			dir := path.Dir(file)
+			if relative := strings.TrimPrefix(pkg, mainModule); strings.HasSuffix(dir, relative) {
+				moduleRoot.Store(strings.TrimSuffix(dir, relative) + "/")
+			}
+		}
+	}
+	if root, ok := moduleRoot.Load().(string); ok && strings.HasPrefix(file, root) {
+		return strings.TrimPrefix(file, root)
+	}
+	if suffix := "/" + pkg + "/" + path.Base(file); pkg != "" && strings.HasSuffix(file, suffix) {
+		return file[len(file)-len(suffix)+1:]
+	}
+	return file
+}
@@ -0,0 +1,79 @@
+package nerrors
+
+import (
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Handler test on frame filters", func() {
+	var previous Config
+
+	ginkgo.BeforeEach(func() {
+		previous = GetConfig()
+	})
+	ginkgo.AfterEach(func() {
+		SetConfig(previous)
+	})
+
+	ginkgo.It("obtains the package of a function", func() {
+		gomega.Expect(functionPackage("github.com/napptive/nerrors/pkg/nerrors.(*ExtendedError).Error")).Should(gomega.Equal("github.com/napptive/nerrors/pkg/nerrors"))
+		gomega.Expect(functionPackage("github.com/onsi/ginkgo/internal/leafnodes.(*runner).runSync.func1")).Should(gomega.Equal("github.com/onsi/ginkgo/internal/leafnodes"))
+		gomega.Expect(functionPackage("runtime.goexit")).Should(gomega.Equal("runtime"))
+		gomega.Expect(functionPackage("main.main")).Should(gomega.Equal("main"))
+		gomega.Expect(functionPackage("")).Should(gomega.Equal(""))
+	})
+	ginkgo.It("drops the frames of the given packages", func() {
+		SetConfig(Config{})
+		gomega.Expect(strings.Join(NewInternalError("internal").StackEntries(), "")).Should(gomega.ContainSubstring("runtime.goexit"))
+
+		SetConfig(Config{DropPackages: []string{"runtime", "github.com/onsi/ginkgo"}})
+		stack := strings.Join(NewInternalError("internal").StackEntries(), "")
+		gomega.Expect(stack).Should(gomega.ContainSubstring("filter_test.go"))
+		gomega.Expect(stack).ShouldNot(gomega.ContainSubstring("runtime.goexit"))
+		gomega.Expect(stack).ShouldNot(gomega.ContainSubstring("github.com/onsi/ginkgo"))
+	})
+	ginkgo.It("collapses the consecutive frames of a library", func() {
+		countGinkgo := func(entries []string) int {
+			count := 0
+			for _, entry := range entries {
+				if strings.Contains(entry, " - github.com/onsi/ginkgo") {
+					count++
+				}
+			}
+			return count
+		}
+		SetConfig(Config{})
+		gomega.Expect(countGinkgo(NewInternalError("internal").StackEntries())).Should(gomega.BeNumerically(">", 1))
+
+		SetConfig(Config{CollapsePackages: []string{"github.com/onsi/ginkgo"}})
+		entries := NewInternalError("internal").StackEntries()
+		gomega.Expect(countGinkgo(entries)).Should(gomega.Equal(1))
+		gomega.Expect(strings.Join(entries, "")).Should(gomega.ContainSubstring("runtime.goexit"))
+	})
+	ginkgo.It("trims the build paths", func() {
+		SetConfig(Config{TrimPaths: true, DropPackages: []string{"github.com/onsi/ginkgo"}})
+		entries := NewInternalError("internal").StackEntries()
+		gomega.Expect(entries[0]).Should(gomega.HavePrefix("pkg/nerrors/nerror.go:"))
+		gomega.Expect(strings.Join(entries, "")).Should(gomega.ContainSubstring("\npkg/nerrors/filter_test.go:"))
+		gomega.Expect(entries[len(entries)-1]).Should(gomega.HavePrefix("runtime/"))
+	})
+	ginkgo.It("trims the build paths of other machines", func() {
+		cfg := &activeConfig{Config: Config{TrimPaths: true}}
+		gomega.Expect(cfg.trimPath("/home/runner/go/pkg/mod/github.com/onsi/ginkgo@v1.15.0/internal/spec/spec.go", "github.com/onsi/ginkgo/internal/spec")).Should(
+			gomega.Equal("github.com/onsi/ginkgo@v1.15.0/internal/spec/spec.go"))
+		gomega.Expect(cfg.trimPath("/opt/hostedtoolcache/go/1.20.1/x64/src/net/http/server.go", "net/http")).Should(gomega.Equal("net/http/server.go"))
+		gomega.Expect(cfg.trimPath("/home/runner/go/src/github.com/acme/app/main.go", "github.com/acme/app")).Should(gomega.Equal("github.com/acme/app/main.go"))
+		gomega.Expect(cfg.trimPath("/home/runner/work/app/http/server.go", "net/http")).Should(gomega.Equal("/home/runner/work/app/http/server.go"))
+		gomega.Expect(cfg.trimPath("main.go", "main")).Should(gomega.Equal("main.go"))
+	})
+	ginkgo.It("sends the filtered stack trace through gRPC", func() {
+		SetConfig(Config{TrimPaths: true, DropPackages: []string{"runtime", "github.com/onsi/ginkgo"}})
+		err := NewInternalError("internal")
+		received := FromGRPC(err.ToGRPC())
+		gomega.Expect(received.StackEntries()).Should(gomega.Equal(err.StackEntries()))
+		gomega.Expect(received.StackTraceToString()).ShouldNot(gomega.ContainSubstring("runtime.goexit"))
+		gomega.Expect(received.StackTraceToString()).Should(gomega.ContainSubstring("pkg/nerrors/filter_test.go:"))
+	})
+})
@@ -0,0 +1,73 @@
+package nerrors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format method to implement fmt.Formatter, compatible with the verbs supported by pkg/errors:
+//   - %s and %v print the error as returned by String.
+//   - %+v prints the error chain with the stack traces as returned by StackTraceToString.
+//   - %q prints the error as returned by String as a quoted string.
+//   - %#v prints a Go-syntax representation of the error chain without the stack traces.
+func (ee *ExtendedError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, ee.StackTraceToString())
+			return
+		}
+		if s.Flag('#') {
+			_, _ = io.WriteString(s, ee.goString())
+			return
+		}
+		_, _ = io.WriteString(s, ee.String())
+	case 's':
+		_, _ = io.WriteString(s, ee.String())
+	case 'q':
+		_, _ = io.WriteString(s, strconv.Quote(ee.String()))
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(*nerrors.ExtendedError=%s)", verb, ee.String())
+	}
+}
+
+// goString returns the Go-syntax representation of the error chain without the stack traces.
+func (ee *ExtendedError) goString() string {
+	if ee == nil {
+		return "(*nerrors.ExtendedError)(nil)"
+	}
+	dump := fmt.Sprintf("&nerrors.ExtendedError{Code:%s, Msg:%q", ee.Code.goString(), Redact(ee.Msg))
+	if ee.From != nil {
+		dump += ", From:" + goStringCause(ee.From)
+	}
+	if len(ee.fields) > 0 {
+		dump += fmt.Sprintf(", fields:%#v", redactFields(ee.fields))
+	}
+	return dump + "}"
+}
+
+// goStringCause returns the Go-syntax representation of a cause. The errors that are not part of this library are
+// written with their type and redacted message, as their own representation is not redacted.
+func goStringCause(err error) string {
+	switch cause := err.(type) {
+	case *ExtendedError:
+		return cause.goString()
+	case *ErrorList:
+		causes := make([]string, 0, len(cause.Errors))
+		for _, listErr := range cause.Errors {
+			causes = append(causes, goStringCause(listErr))
+		}
+		return "&nerrors.ErrorList{Errors:[]error{" + strings.Join(causes, ", ") + "}}"
+	}
+	return fmt.Sprintf("%T(%q)", err, Redact(err.Error()))
+}
+
+// goString returns the Go-syntax representation of the code.
+func (ec ErrorCode) goString() string {
+	if _, exists := ToGRPCCode[ec]; exists {
+		return "nerrors." + ec.String()
+	}
+	return fmt.Sprintf("nerrors.ErrorCode(%d)", int(ec))
+}
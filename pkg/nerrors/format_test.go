@@ -0,0 +1,48 @@
+package nerrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Handler test on format", func() {
+	ginkgo.It("prints the error with %s and %v", func() {
+		err := NewNotFoundErrorFrom(errors.New("no rows"), "user %s not found", "u1")
+		gomega.Expect(fmt.Sprintf("%s", err)).Should(gomega.Equal(err.String()))
+		gomega.Expect(fmt.Sprintf("%v", err)).Should(gomega.Equal(err.String()))
+		gomega.Expect(fmt.Sprint(err)).Should(gomega.Equal(err.String()))
+	})
+	ginkgo.It("prints the stack traces with %+v", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal")
+		output := fmt.Sprintf("%+v", err)
+		gomega.Expect(output).Should(gomega.Equal(err.StackTraceToString()))
+		gomega.Expect(output).Should(gomega.ContainSubstring("format_test.go"))
+		gomega.Expect(output).Should(gomega.ContainSubstring("Caused by [NotFound] not found"))
+	})
+	ginkgo.It("quotes the error with %q", func() {
+		err := NewInvalidArgumentError(`invalid "name"`)
+		gomega.Expect(fmt.Sprintf("%q", err)).Should(gomega.Equal(`"[InvalidArgument] invalid \"name\""`))
+	})
+	ginkgo.It("dumps the error chain with %#v", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("not found").WithField("id", 3), "internal")
+		output := fmt.Sprintf("%#v", err)
+		gomega.Expect(output).Should(gomega.Equal(
+			`&nerrors.ExtendedError{Code:nerrors.Internal, Msg:"internal", From:&nerrors.ExtendedError{Code:nerrors.NotFound, Msg:"not found", fields:map[string]interface {}{"id":3}}}`))
+		gomega.Expect(output).ShouldNot(gomega.ContainSubstring("format_test.go"))
+	})
+	ginkgo.It("dumps foreign causes and lists with %#v", func() {
+		err := NewInternalErrorFrom(Join(NewNotFoundError("a"), errors.New("b")), "internal")
+		output := fmt.Sprintf("%#v", err)
+		gomega.Expect(output).Should(gomega.HavePrefix(
+			`&nerrors.ExtendedError{Code:nerrors.Internal, Msg:"internal", From:&nerrors.ErrorList{Errors:[]error{&nerrors.ExtendedError{Code:nerrors.NotFound, Msg:"a"}, `))
+		gomega.Expect(fmt.Sprintf("%#v", &ExtendedError{Code: ErrorCode(42)})).Should(gomega.Equal(`&nerrors.ExtendedError{Code:nerrors.ErrorCode(42), Msg:""}`))
+	})
+	ginkgo.It("supports the wrapping verb of fmt.Errorf", func() {
+		err := fmt.Errorf("loading: %w", NewNotFoundError("not found"))
+		gomega.Expect(err.Error()).Should(gomega.Equal("loading: [NotFound] not found"))
+		gomega.Expect(errors.Is(err, NotFound)).Should(gomega.BeTrue())
+	})
+})
@@ -0,0 +1,124 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Frame with the information of a frame of a stack trace.
+type Frame struct {
+	// Function with the fully qualified name of the function.
+	Function string `json:"function"`
+	// Package with the path of the package of the function.
+	Package string `json:"package,omitempty"`
+	// File with the path of the source file.
+	File string `json:"file,omitempty"`
+	// Line with the line number in the source file.
+	Line int `json:"line,omitempty"`
+	// PC with the program counter of the frame. It is only meaningful in the process that captured it, and only kept
+	// if Config.LazyStackTrace is set.
+	PC uintptr `json:"pc,omitempty"`
+}
+
+// String returns the frame with the format "file:line - function", or only the function if the file is not known.
+func (f Frame) String() string {
+	if f.File == "" {
+		return f.Function
+	}
+	return fmt.Sprintf("%s:%d - %s", f.File, f.Line, f.Function)
+}
+
+// UnmarshalJSON method to implement json.Unmarshaler. Besides the object written by json.Marshal, it accepts the
+// textual entries written by previous versions of the library.
+func (f *Frame) UnmarshalJSON(data []byte) error {
+	var entry string
+	if err := json.Unmarshal(data, &entry); err == nil {
+		*f = parseFrame(entry)
+		return nil
+	}
+	type plainFrame Frame
+	return json.Unmarshal(data, (*plainFrame)(f))
+}
+
+// StackTrace with the frames related to where an error happened, from the innermost to the outermost.
+type StackTrace []Frame
+
+// String returns the stack trace with one frame per line.
+func (st StackTrace) String() string {
+	return strings.Join(st.Strings(), "")
+}
+
+// Strings returns the frames with the format "file:line - function\n" used by the StackTrace field of the
+// ExtendedError.
+func (st StackTrace) Strings() []string {
+	if st == nil {
+		return nil
+	}
+	entries := make([]string, len(st))
+	for i, frame := range st {
+		entries[i] = frame.String() + "\n"
+	}
+	return entries
+}
+
+// Filter returns the frames for which keep returns true.
+func (st StackTrace) Filter(keep func(Frame) bool) StackTrace {
+	filtered := make(StackTrace, 0, len(st))
+	for _, frame := range st {
+		if keep(frame) {
+			filtered = append(filtered, frame)
+		}
+	}
+	return filtered
+}
+
+// sharedFrames returns the number of frames at the end of the stack trace that are also at the end of the enclosing
+// one according to the given comparison.
+func (st StackTrace) sharedFrames(enclosing StackTrace, equal func(Frame, Frame) bool) int {
+	shared := 0
+	for shared < len(st) && shared < len(enclosing) && equal(st[len(st)-1-shared], enclosing[len(enclosing)-1-shared]) {
+		shared++
+	}
+	return shared
+}
+
+// sameFrame compares two frames including their program counters, so the shared frames can be restored exactly.
+func sameFrame(a Frame, b Frame) bool {
+	return a == b
+}
+
+// sameLocation compares the rendered information of two frames, ignoring their program counters.
+func sameLocation(a Frame, b Frame) bool {
+	a.PC, b.PC = 0, 0
+	return a == b
+}
+
+// parseStackTrace converts the entries of the StackTrace field into frames.
+func parseStackTrace(entries []string) StackTrace {
+	if entries == nil {
+		return nil
+	}
+	stackTrace := make(StackTrace, len(entries))
+	for i, entry := range entries {
+		stackTrace[i] = parseFrame(entry)
+	}
+	return stackTrace
+}
+
+// parseFrame parses an entry of the stack trace with the format "file:line - function". Entries that do not follow
+// the format are kept as the function.
+func parseFrame(entry string) Frame {
+	entry = strings.TrimSuffix(entry, "\n")
+	if sep := strings.LastIndex(entry, " - "); sep >= 0 {
+		location := entry[:sep]
+		if colon := strings.LastIndex(location, ":"); colon >= 0 {
+			if line, err := strconv.Atoi(location[colon+1:]); err == nil {
+				function := entry[sep+3:]
+				return Frame{Function: function, Package: functionPackage(function), File: location[:colon], Line: line}
+			}
+		}
+	}
+	return Frame{Function: entry}
+}
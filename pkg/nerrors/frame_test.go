@@ -0,0 +1,84 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Handler test on frames", func() {
+	ginkgo.It("captures the frames of the stack trace", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{LazyStackTrace: true})
+		frames := NewNotFoundError("not found").Frames()
+		gomega.Expect(frames).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(frames[0].Function).Should(gomega.Equal("github.com/napptive/nerrors/pkg/nerrors.NewExtendedError"))
+		gomega.Expect(frames[0].Package).Should(gomega.Equal("github.com/napptive/nerrors/pkg/nerrors"))
+		gomega.Expect(frames[0].File).Should(gomega.HaveSuffix("nerror.go"))
+		gomega.Expect(frames[0].Line).Should(gomega.BeNumerically(">", 0))
+		gomega.Expect(frames[0].PC).ShouldNot(gomega.BeZero())
+		gomega.Expect(frames[2].File).Should(gomega.HaveSuffix("frame_test.go"))
+	})
+	ginkgo.It("renders the frames", func() {
+		frames := StackTrace{
+			{Function: "main.run", Package: "main", File: "main.go", Line: 7},
+			{Function: "main.main", Package: "main", File: "main.go", Line: 3},
+			{Function: "unknown entry"},
+		}
+		gomega.Expect(frames[0].String()).Should(gomega.Equal("main.go:7 - main.run"))
+		gomega.Expect(frames.Strings()).Should(gomega.Equal([]string{"main.go:7 - main.run\n", "main.go:3 - main.main\n", "unknown entry\n"}))
+		gomega.Expect(frames.String()).Should(gomega.Equal("main.go:7 - main.run\nmain.go:3 - main.main\nunknown entry\n"))
+		gomega.Expect(frames.Filter(func(frame Frame) bool {
+			return frame.Function != "main.run"
+		})).Should(gomega.Equal(frames[1:]))
+	})
+	ginkgo.It("keeps the entries of the StackTrace field in sync", func() {
+		err := NewNotFoundError("not found")
+		gomega.Expect(err.StackEntries()).Should(gomega.Equal(err.Frames().Strings()))
+		parsed := parseStackTrace(err.StackEntries())
+		gomega.Expect(parsed).Should(gomega.HaveLen(len(err.Frames())))
+		for i, frame := range err.Frames() {
+			frame.PC = 0
+			gomega.Expect(parsed[i]).Should(gomega.Equal(frame))
+		}
+	})
+	ginkgo.It("parses the entries of previous versions", func() {
+		gomega.Expect(parseFrame("/src/app/main.go:3 - github.com/org/app/pkg.(*Server).Run\n")).Should(gomega.Equal(Frame{
+			Function: "github.com/org/app/pkg.(*Server).Run",
+			Package:  "github.com/org/app/pkg",
+			File:     "/src/app/main.go",
+			Line:     3,
+		}))
+		gomega.Expect(parseFrame("not a frame\n")).Should(gomega.Equal(Frame{Function: "not a frame"}))
+	})
+	ginkgo.It("sends the frames through gRPC", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal")
+		received := FromGRPC(err.ToGRPC())
+		gomega.Expect(received.Frames()).Should(gomega.Equal(err.Frames()))
+		gomega.Expect(received.From.(*ExtendedError).Frames()).Should(gomega.Equal(err.From.(*ExtendedError).Frames()))
+	})
+	ginkgo.It("writes the frames as JSON objects", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{LazyStackTrace: true})
+		err := NewNotFoundError("not found")
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+		decoded := map[string]interface{}{}
+		gomega.Expect(json.Unmarshal(raw, &decoded)).Should(gomega.Succeed())
+		first := decoded["stack"].([]interface{})[0].(map[string]interface{})
+		gomega.Expect(first["function"]).Should(gomega.Equal("github.com/napptive/nerrors/pkg/nerrors.NewExtendedError"))
+		gomega.Expect(first["package"]).Should(gomega.Equal("github.com/napptive/nerrors/pkg/nerrors"))
+		gomega.Expect(first).Should(gomega.HaveKey("pc"))
+	})
+	ginkgo.It("reads the JSON stack traces of previous versions", func() {
+		restored := &ExtendedError{}
+		raw := `{"code":"NotFound","message":"not found","stack":["main.go:3 - main.main\n"]}`
+		gomega.Expect(json.Unmarshal([]byte(raw), restored)).Should(gomega.Succeed())
+		gomega.Expect(restored.Frames()).Should(gomega.Equal(StackTrace{{Function: "main.main", Package: "main", File: "main.go", Line: 3}}))
+		gomega.Expect(strings.Join(restored.StackEntries(), "")).Should(gomega.Equal("main.go:3 - main.main\n"))
+	})
+})
@@ -0,0 +1,137 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// HTTPError with the JSON representation of an error sent to HTTP clients.
+type HTTPError struct {
+	// Code with the name of the error code.
+	Code string `json:"code"`
+	// Message with a textual description of the error.
+	Message string `json:"message"`
+	// Causes with the errors that caused this one, from the outermost to the innermost.
+	Causes []HTTPErrorCause `json:"causes,omitempty"`
+	// IncidentID with the incident ID assigned by a Boundary, if any.
+	IncidentID string `json:"incident_id,omitempty"`
+}
+
+// HTTPErrorCause with the JSON representation of an error of the chain.
+type HTTPErrorCause struct {
+	// Code with the name of the error code.
+	Code string `json:"code"`
+	// Message with a textual description of the error.
+	Message string `json:"message"`
+}
+
+// ToHTTPError converts the error chain into its HTTP representation.
+func (ee *ExtendedError) ToHTTPError() *HTTPError {
+	body := &HTTPError{
+		Code:       ee.Code.String(),
+		Message:    Redact(ee.message()),
+		IncidentID: ee.IncidentID(),
+	}
+	for _, cause := range ee.causes() {
+		body.Causes = append(body.Causes, HTTPErrorCause{Code: cause.Code.String(), Message: Redact(cause.Msg)})
+	}
+	return body
+}
+
+// WriteError writes an error as a JSON response with the HTTP status code related to its ErrorCode.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	extended := FromError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	setRetryAfterHeader(w, extended)
+	w.WriteHeader(extended.Code.HTTPStatus())
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(extended.ToHTTPError())
+}
+
+// HandlerFunc is an HTTP handler that returns an error. The error is written with WriteError.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP method to implement the http.Handler interface.
+func (hf HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, hf(w, r))
+}
+
+// HTTPMiddleware returns an HTTP middleware that converts the panics of the wrapped handler into Internal errors
+// written with WriteError. If the handler already started the response, the error is logged and the response is
+// aborted with http.ErrAbortHandler, as the status code and part of the body were sent.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return httpMiddleware(next, WriteError, func(err *ExtendedError) {
+		log.Printf("%s", err.StackTraceToString())
+	})
+}
+
+// HTTPMiddleware returns an HTTP middleware that converts the panics of the wrapped handler into Internal errors
+// written with the WriteError method of the boundary. If the handler already started the response, the error is
+// logged as an incident and the response is aborted with http.ErrAbortHandler.
+func (b *Boundary) HTTPMiddleware(next http.Handler) http.Handler {
+	return httpMiddleware(next, b.WriteError, func(err *ExtendedError) {
+		b.log(b.incidentID(), err)
+	})
+}
+
+// httpMiddleware returns an HTTP middleware that converts the panics of the wrapped handler into Internal errors
+// written with the given function, or logged with the given function if the response already started.
+func httpMiddleware(next http.Handler, writeError func(w http.ResponseWriter, r *http.Request, err error),
+	logError func(err *ExtendedError)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracked := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				// http.ErrAbortHandler is used to abort the response on purpose.
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				var err *ExtendedError
+				if cause, ok := recovered.(error); ok {
+					err = NewInternalErrorFrom(cause, "panic serving %s %s", r.Method, r.URL.Path)
+				} else {
+					err = NewInternalError("panic serving %s %s: %s", r.Method, r.URL.Path, fmt.Sprint(recovered))
+				}
+				if tracked.started {
+					logError(err)
+					panic(http.ErrAbortHandler)
+				}
+				writeError(w, r, err)
+			}
+		}()
+		next.ServeHTTP(tracked, r)
+	})
+}
+
+// responseWriter tracks whether the response of the wrapped writer started, so the errors are not written after the
+// status code or the body. It does not implement the optional interfaces, such as http.Flusher or http.Hijacker, as
+// the wrapped writer may not implement them: handlers reach them with http.ResponseController, which unwraps it.
+type responseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+// WriteHeader method to implement http.ResponseWriter.
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	rw.started = true
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write method to implement http.ResponseWriter.
+func (rw *responseWriter) Write(data []byte) (int, error) {
+	rw.started = true
+	return rw.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the wrapped writer, used by http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,152 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// serve executes a request against the handler and returns the response.
+func serve(handler http.Handler, method string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, "/apps/test", nil))
+	return recorder
+}
+
+var _ = ginkgo.Describe("Handler test on HTTP errors", func() {
+	ginkgo.Context("status mapping", func() {
+		ginkgo.It("maps the codes to HTTP status", func() {
+			gomega.Expect(OK.HTTPStatus()).Should(gomega.Equal(http.StatusOK))
+			gomega.Expect(NotFound.HTTPStatus()).Should(gomega.Equal(http.StatusNotFound))
+			gomega.Expect(InvalidArgument.HTTPStatus()).Should(gomega.Equal(http.StatusBadRequest))
+			gomega.Expect(ResourceExhausted.HTTPStatus()).Should(gomega.Equal(http.StatusTooManyRequests))
+			gomega.Expect(Unauthenticated.HTTPStatus()).Should(gomega.Equal(http.StatusUnauthorized))
+			gomega.Expect(Canceled.HTTPStatus()).Should(gomega.Equal(499))
+			gomega.Expect(ErrorCode(100).HTTPStatus()).Should(gomega.Equal(http.StatusInternalServerError))
+		})
+		ginkgo.It("maps the HTTP status to codes", func() {
+			gomega.Expect(FromHTTPStatus(http.StatusNoContent)).Should(gomega.Equal(OK))
+			gomega.Expect(FromHTTPStatus(http.StatusNotFound)).Should(gomega.Equal(NotFound))
+			gomega.Expect(FromHTTPStatus(http.StatusTeapot)).Should(gomega.Equal(InvalidArgument))
+			gomega.Expect(FromHTTPStatus(http.StatusBadGateway)).Should(gomega.Equal(Internal))
+			gomega.Expect(FromHTTPStatus(http.StatusMovedPermanently)).Should(gomega.Equal(Unknown))
+		})
+		ginkgo.It("maps the status of each code back to the same code", func() {
+			for _, code := range []ErrorCode{OK, Canceled, InvalidArgument, DeadlineExceeded, NotFound, AlreadyExists,
+				PermissionDenied, ResourceExhausted, Unimplemented, Internal, Unavailable, Unauthenticated} {
+				gomega.Expect(FromHTTPStatus(code.HTTPStatus())).Should(gomega.Equal(code))
+			}
+		})
+	})
+	ginkgo.Context("writing errors", func() {
+		ginkgo.It("writes the error chain as JSON", func() {
+			handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return NewNotFoundErrorFrom(fmt.Errorf("standard error"), "app %s not found", "test")
+			})
+			response := serve(handler, http.MethodGet)
+			gomega.Expect(response.Code).Should(gomega.Equal(http.StatusNotFound))
+			gomega.Expect(response.Header().Get("Content-Type")).Should(gomega.Equal("application/json"))
+
+			body := &HTTPError{}
+			gomega.Expect(json.Unmarshal(response.Body.Bytes(), body)).Should(gomega.Succeed())
+			gomega.Expect(body).Should(gomega.Equal(&HTTPError{
+				Code:    "NotFound",
+				Message: "app test not found",
+				Causes:  []HTTPErrorCause{{Code: "Unknown", Message: "standard error"}},
+			}))
+		})
+		ginkgo.It("does not repeat the cause that gives the message", func() {
+			_, openErr := os.Open("/this/file/does/not/exist")
+			body := FromError(openErr).ToHTTPError()
+			gomega.Expect(body.Code).Should(gomega.Equal("NotFound"))
+			gomega.Expect(body.Message).Should(gomega.Equal(openErr.Error()))
+			gomega.Expect(body.Causes).Should(gomega.BeEmpty())
+
+			body = FromError(fmt.Errorf("loading: %w", NewNotFoundError("not found"))).ToHTTPError()
+			gomega.Expect(body.Message).Should(gomega.Equal("loading: [NotFound] not found"))
+			gomega.Expect(body.Causes).Should(gomega.Equal([]HTTPErrorCause{{Code: "NotFound", Message: "not found"}}))
+		})
+		ginkgo.It("does not write anything without errors", func() {
+			handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				w.WriteHeader(http.StatusAccepted)
+				return nil
+			})
+			response := serve(handler, http.MethodGet)
+			gomega.Expect(response.Code).Should(gomega.Equal(http.StatusAccepted))
+			gomega.Expect(response.Body.Len()).Should(gomega.BeZero())
+		})
+		ginkgo.It("does not write a body on HEAD requests", func() {
+			handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return NewPermissionDeniedError("permission denied")
+			})
+			response := serve(handler, http.MethodHead)
+			gomega.Expect(response.Code).Should(gomega.Equal(http.StatusForbidden))
+			gomega.Expect(response.Body.Len()).Should(gomega.BeZero())
+		})
+	})
+	ginkgo.Context("middleware", func() {
+		ginkgo.It("converts panics into Internal errors", func() {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("something went wrong")
+			}))
+			response := serve(handler, http.MethodGet)
+			gomega.Expect(response.Code).Should(gomega.Equal(http.StatusInternalServerError))
+			body := &HTTPError{}
+			gomega.Expect(json.Unmarshal(response.Body.Bytes(), body)).Should(gomega.Succeed())
+			gomega.Expect(body.Code).Should(gomega.Equal("Internal"))
+			gomega.Expect(body.Message).Should(gomega.ContainSubstring("something went wrong"))
+		})
+		ginkgo.It("keeps the error of the panic as the cause", func() {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(fmt.Errorf("standard error"))
+			}))
+			body := &HTTPError{}
+			gomega.Expect(json.Unmarshal(serve(handler, http.MethodGet).Body.Bytes(), body)).Should(gomega.Succeed())
+			gomega.Expect(body.Causes).Should(gomega.HaveLen(1))
+			gomega.Expect(body.Causes[0].Message).Should(gomega.Equal("standard error"))
+		})
+		ginkgo.It("aborts the responses that already started", func() {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("partial"))
+				panic("something went wrong")
+			}))
+			recorder := httptest.NewRecorder()
+			recovered := func() (recovered interface{}) {
+				defer func() {
+					recovered = recover()
+				}()
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/apps/test", nil))
+				return nil
+			}()
+			gomega.Expect(recovered).Should(gomega.Equal(http.ErrAbortHandler))
+			gomega.Expect(recorder.Code).Should(gomega.Equal(http.StatusOK))
+			gomega.Expect(recorder.Body.String()).Should(gomega.Equal("partial"))
+		})
+		ginkgo.It("only exposes the optional interfaces through the response controller", func() {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, isHijacker := w.(http.Hijacker)
+				gomega.Expect(isHijacker).Should(gomega.BeFalse())
+				_, _ = w.Write([]byte("partial"))
+				gomega.Expect(http.NewResponseController(w).Flush()).Should(gomega.Succeed())
+				_, _, err := http.NewResponseController(w).Hijack()
+				gomega.Expect(errors.Is(err, http.ErrNotSupported)).Should(gomega.BeTrue())
+			}))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/apps/test", nil))
+			gomega.Expect(recorder.Flushed).Should(gomega.BeTrue())
+		})
+		ginkgo.It("does not recover aborted handlers", func() {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			}))
+			gomega.Expect(func() { serve(handler, http.MethodGet) }).Should(gomega.Panic())
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package nerrors
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ServerOption configures the server interceptors.
+type ServerOption func(*serverOptions)
+
+// serverOptions with the configuration of the server interceptors.
+type serverOptions struct {
+	boundary *Boundary
+}
+
+// WithBoundary sanitises the errors returned by the handlers with the given boundary before sending them.
+func WithBoundary(boundary *Boundary) ServerOption {
+	return func(options *serverOptions) {
+		options.boundary = boundary
+	}
+}
+
+// newServerOptions applies the given options.
+func newServerOptions(opts []ServerOption) *serverOptions {
+	options := &serverOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// UnaryServerInterceptor returns a gRPC unary server interceptor that converts the errors returned by the handlers
+// into gRPC errors using ToGRPC, so the whole error chain is sent to the client.
+func UnaryServerInterceptor(opts ...ServerOption) grpc.UnaryServerInterceptor {
+	options := newServerOptions(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, options.toGRPCError(err)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC stream server interceptor that converts the errors returned by the handlers
+// into gRPC errors using ToGRPC, so the whole error chain is sent to the client.
+func StreamServerInterceptor(opts ...ServerOption) grpc.StreamServerInterceptor {
+	options := newServerOptions(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return options.toGRPCError(handler(srv, ss))
+	}
+}
+
+// toGRPCError normalises an error returned by a handler. Without a boundary, errors that are already gRPC status
+// errors are returned untouched. With a boundary, every error is sanitised.
+func (so *serverOptions) toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if so.boundary != nil {
+		return so.boundary.Sanitize(err).ToGRPC()
+	}
+	if _, isStatus := status.FromError(err); isStatus {
+		return err
+	}
+	return FromError(err).ToGRPC()
+}
+
+// UnaryClientInterceptor returns a gRPC unary client interceptor that converts the errors received from the server
+// into extended errors using FromGRPC. The resulting chain is tagged with the method name and the peer address.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p := &peer.Peer{}
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(p))...)
+		return fromGRPCError(err, method, peerAddress(p, cc))
+	}
+}
+
+// StreamClientInterceptor returns a gRPC stream client interceptor that converts the errors received from the server
+// into extended errors using FromGRPC. The resulting chain is tagged with the method name and the peer address.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		p := &peer.Peer{}
+		cs, err := streamer(ctx, desc, cc, method, append(opts, grpc.Peer(p))...)
+		if err != nil {
+			return nil, fromGRPCError(err, method, peerAddress(p, cc))
+		}
+		return &clientStream{ClientStream: cs, method: method, peer: p, cc: cc}, nil
+	}
+}
+
+// clientStream wraps a grpc.ClientStream converting the errors received from the server.
+type clientStream struct {
+	grpc.ClientStream
+	method string
+	peer   *peer.Peer
+	cc     *grpc.ClientConn
+}
+
+func (cs *clientStream) Header() (metadata.MD, error) {
+	md, err := cs.ClientStream.Header()
+	return md, cs.convert(err)
+}
+
+func (cs *clientStream) CloseSend() error {
+	return cs.convert(cs.ClientStream.CloseSend())
+}
+
+func (cs *clientStream) SendMsg(m interface{}) error {
+	return cs.convert(cs.ClientStream.SendMsg(m))
+}
+
+func (cs *clientStream) RecvMsg(m interface{}) error {
+	return cs.convert(cs.ClientStream.RecvMsg(m))
+}
+
+// convert transforms the error received from the stream. io.EOF is returned untouched as it signals the end of
+// the stream.
+func (cs *clientStream) convert(err error) error {
+	if err == io.EOF {
+		return err
+	}
+	return fromGRPCError(err, cs.method, peerAddress(cs.peer, cs.cc))
+}
+
+// fromGRPCError rebuilds the extended error chain received from the server, tagging it with the method and the
+// address of the peer that returned it.
+func fromGRPCError(err error, method string, address string) error {
+	if err == nil {
+		return nil
+	}
+	remote := FromGRPC(err)
+	return NewExtendedErrorFrom(remote.Code, remote, "%s failed on %s", method, address)
+}
+
+// peerAddress returns the address of the peer, or the target of the connection if the call did not reach any peer.
+func peerAddress(p *peer.Peer, cc *grpc.ClientConn) string {
+	if p.Addr != nil {
+		return p.Addr.String()
+	}
+	return cc.Target()
+}
@@ -0,0 +1,203 @@
+package nerrors
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// failingHealthServer is a health server that always fails with the configured error.
+type failingHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	err error
+}
+
+func (fhs *failingHealthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, fhs.err
+}
+
+func (fhs *failingHealthServer) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health_WatchServer) error {
+	return fhs.err
+}
+
+// testServer launches a gRPC server over bufconn with the given options, and returns a client connection to it.
+func testServer(service grpc_health_v1.HealthServer, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (*grpc.Server, *grpc.ClientConn) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(serverOpts...)
+	grpc_health_v1.RegisterHealthServer(server, service)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	dialOpts = append(dialOpts, grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	conn, err := grpc.DialContext(context.Background(), "bufnet", dialOpts...)
+	gomega.Expect(err).Should(gomega.Succeed())
+	return server, conn
+}
+
+// watchError opens a Watch stream and returns the error received on it.
+func watchError(client grpc_health_v1.HealthClient) error {
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	_, err = stream.Recv()
+	return err
+}
+
+var _ = ginkgo.Describe("Handler test on gRPC server interceptors", func() {
+	var service *failingHealthServer
+	var server *grpc.Server
+	var conn *grpc.ClientConn
+	var client grpc_health_v1.HealthClient
+
+	ginkgo.BeforeEach(func() {
+		service = &failingHealthServer{}
+		server, conn = testServer(service, []grpc.ServerOption{
+			grpc.UnaryInterceptor(UnaryServerInterceptor()),
+			grpc.StreamInterceptor(StreamServerInterceptor()),
+		})
+		client = grpc_health_v1.NewHealthClient(conn)
+	})
+
+	ginkgo.AfterEach(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	ginkgo.Context("unary calls", func() {
+		ginkgo.It("converts an extended error", func() {
+			service.err = NewInternalErrorFrom(NewNotFoundError("not found"), "internal error")
+			_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.Internal))
+
+			converted := FromGRPC(err)
+			gomega.Expect(converted.Code).Should(gomega.Equal(Internal))
+			gomega.Expect(converted.Msg).Should(gomega.Equal("internal error"))
+			gomega.Expect(converted.From).ShouldNot(gomega.BeNil())
+			gomega.Expect(FromError(converted.From).Code).Should(gomega.Equal(NotFound))
+		})
+		ginkgo.It("converts a standard error", func() {
+			service.err = fmt.Errorf("standard error")
+			_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.Unknown))
+			gomega.Expect(status.Convert(err).Details()).ShouldNot(gomega.BeEmpty())
+			gomega.Expect(FromGRPC(err).Msg).Should(gomega.Equal("standard error"))
+		})
+		ginkgo.It("passes gRPC errors untouched", func() {
+			service.err = status.Error(codes.AlreadyExists, "already exists")
+			_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.AlreadyExists))
+			gomega.Expect(status.Convert(err).Message()).Should(gomega.Equal("already exists"))
+			gomega.Expect(status.Convert(err).Details()).Should(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.Context("stream calls", func() {
+		ginkgo.It("converts an extended error", func() {
+			service.err = NewPermissionDeniedError("permission denied")
+			err := watchError(client)
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.PermissionDenied))
+			gomega.Expect(FromGRPC(err).Msg).Should(gomega.Equal("permission denied"))
+		})
+		ginkgo.It("passes gRPC errors untouched", func() {
+			service.err = status.Error(codes.Unavailable, "unavailable")
+			err := watchError(client)
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.Unavailable))
+			gomega.Expect(status.Convert(err).Details()).Should(gomega.BeEmpty())
+		})
+	})
+})
+
+// forwardingHealthServer is a health server that forwards the calls to another health server.
+type forwardingHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	client grpc_health_v1.HealthClient
+}
+
+func (fhs *forwardingHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return fhs.client.Check(ctx, req)
+}
+
+var _ = ginkgo.Describe("Handler test on gRPC client interceptors", func() {
+	var service *failingHealthServer
+	var server *grpc.Server
+	var conn *grpc.ClientConn
+	var client grpc_health_v1.HealthClient
+
+	ginkgo.BeforeEach(func() {
+		service = &failingHealthServer{}
+		server, conn = testServer(service, []grpc.ServerOption{
+			grpc.UnaryInterceptor(UnaryServerInterceptor()),
+			grpc.StreamInterceptor(StreamServerInterceptor()),
+		}, grpc.WithUnaryInterceptor(UnaryClientInterceptor()), grpc.WithStreamInterceptor(StreamClientInterceptor()))
+		client = grpc_health_v1.NewHealthClient(conn)
+	})
+
+	ginkgo.AfterEach(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	ginkgo.It("rehydrates the error chain on unary calls", func() {
+		service.err = NewInternalErrorFrom(NewNotFoundError("not found"), "internal error")
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		extended, ok := err.(*ExtendedError)
+		gomega.Expect(ok).Should(gomega.BeTrue())
+		gomega.Expect(extended.Code).Should(gomega.Equal(Internal))
+		gomega.Expect(extended.Msg).Should(gomega.ContainSubstring("/grpc.health.v1.Health/Check"))
+		gomega.Expect(extended.Msg).Should(gomega.ContainSubstring("bufconn"))
+
+		remote := FromError(extended.From)
+		gomega.Expect(remote.Code).Should(gomega.Equal(Internal))
+		gomega.Expect(remote.Msg).Should(gomega.Equal("internal error"))
+		gomega.Expect(FromError(remote.From).Code).Should(gomega.Equal(NotFound))
+	})
+	ginkgo.It("rehydrates plain gRPC errors", func() {
+		service.err = status.Error(codes.AlreadyExists, "already exists")
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		extended, ok := err.(*ExtendedError)
+		gomega.Expect(ok).Should(gomega.BeTrue())
+		gomega.Expect(extended.Code).Should(gomega.Equal(AlreadyExists))
+		gomega.Expect(FromError(extended.From).Msg).Should(gomega.Equal("already exists"))
+	})
+	ginkgo.It("rehydrates the error chain on stream calls", func() {
+		service.err = NewPermissionDeniedError("permission denied")
+		err := watchError(client)
+		extended, ok := err.(*ExtendedError)
+		gomega.Expect(ok).Should(gomega.BeTrue())
+		gomega.Expect(extended.Code).Should(gomega.Equal(PermissionDenied))
+		gomega.Expect(extended.Msg).Should(gomega.ContainSubstring("/grpc.health.v1.Health/Watch"))
+		gomega.Expect(extended.Msg).Should(gomega.ContainSubstring("bufconn"))
+		gomega.Expect(FromError(extended.From).Msg).Should(gomega.Equal("permission denied"))
+	})
+	ginkgo.It("tags every hop of a chain crossing several services", func() {
+		service.err = NewNotFoundError("not found")
+		middleServer, middleConn := testServer(&forwardingHealthServer{client: client}, []grpc.ServerOption{
+			grpc.UnaryInterceptor(UnaryServerInterceptor()),
+		}, grpc.WithUnaryInterceptor(UnaryClientInterceptor()))
+		defer middleServer.Stop()
+		defer middleConn.Close()
+
+		_, err := grpc_health_v1.NewHealthClient(middleConn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		chain := make([]string, 0)
+		for current := FromError(err); current != nil; current, _ = current.From.(*ExtendedError) {
+			gomega.Expect(current.Code).Should(gomega.Equal(NotFound))
+			chain = append(chain, current.Msg)
+		}
+		gomega.Expect(chain).Should(gomega.HaveLen(3))
+		gomega.Expect(chain[0]).Should(gomega.ContainSubstring("/grpc.health.v1.Health/Check failed on"))
+		gomega.Expect(chain[1]).Should(gomega.ContainSubstring("/grpc.health.v1.Health/Check failed on"))
+		gomega.Expect(chain[2]).Should(gomega.Equal("not found"))
+	})
+})
@@ -0,0 +1,174 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// jsonError with the JSON representation of an error of the chain. Errors that are not extended errors have no
+// code, and include the name of their go type instead. The errors aggregated by an ErrorList are stored as causes.
+// The retry delay is written as a duration such as "1.5s", and the protobuf details with their type URL and encoded
+// value, so the ones of types unknown to the reader are kept too.
+type jsonError struct {
+	Code       string                 `json:"code,omitempty"`
+	Type       string                 `json:"type,omitempty"`
+	Message    string                 `json:"message"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+	RetryAfter string                 `json:"retry_after,omitempty"`
+	Details    []*jsonDetail          `json:"details,omitempty"`
+	Stack      StackTrace             `json:"stack,omitempty"`
+	Cause      *jsonError             `json:"cause,omitempty"`
+	Causes     []*jsonError           `json:"causes,omitempty"`
+}
+
+// jsonDetail with the JSON representation of a protobuf detail as a google.protobuf.Any. The value is written in
+// base64.
+type jsonDetail struct {
+	TypeURL string `json:"type_url"`
+	Value   []byte `json:"value"`
+}
+
+// errorListType with the name of the go type of ErrorList.
+var errorListType = reflect.TypeOf(&ErrorList{}).String()
+
+// ForeignError is the representation of an error that was not an extended error, once decoded from JSON.
+type ForeignError struct {
+	// Type with the name of the go type of the original error.
+	Type string
+	// Msg with the message of the original error.
+	Msg string
+	// From links with the error wrapped by the original error if any.
+	From error
+}
+
+// Error method to implement error interface
+func (fe *ForeignError) Error() string {
+	return fe.Msg
+}
+
+// Unwrap method to implement Wrapper interface
+func (fe *ForeignError) Unwrap() error {
+	return fe.From
+}
+
+// MarshalJSON method to implement json.Marshaler. The code is written as its name, and the whole chain is included.
+func (ee *ExtendedError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONError(ee, true))
+}
+
+// UnmarshalJSON method to implement json.Unmarshaler. It restores the chain written by MarshalJSON. The fields follow
+// the JSON semantics, the same as through gRPC: numbers are decoded as float64, and the values of other types as
+// their JSON representation (strings, booleans, nil, []interface{} or map[string]interface{}).
+func (ee *ExtendedError) UnmarshalJSON(data []byte) error {
+	decoded := &jsonError{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+	if decoded.Code == "" {
+		decoded.Code = Unknown.String()
+	}
+	restored, err := fromJSONError(decoded)
+	if err != nil {
+		return err
+	}
+	*ee = *restored.(*ExtendedError)
+	return nil
+}
+
+// toJSONError converts an error of the chain into its JSON representation, with or without the stack traces.
+func toJSONError(err error, withStack bool) *jsonError {
+	if extended, ok := err.(*ExtendedError); ok {
+		encoded := &jsonError{
+			Code:    extended.Code.String(),
+			Message: Redact(extended.Msg),
+			Fields:  redactFields(extended.fields),
+		}
+		if extended.retryAfter > 0 {
+			encoded.RetryAfter = extended.retryAfter.String()
+		}
+		for _, detail := range extended.details {
+			encoded.Details = append(encoded.Details, &jsonDetail{TypeURL: detail.GetTypeUrl(), Value: detail.GetValue()})
+		}
+		if withStack {
+			encoded.Stack = extended.Frames()
+		}
+		if extended.From != nil {
+			encoded.Cause = toJSONError(extended.From, withStack)
+		}
+		return encoded
+	}
+	if list, ok := err.(*ErrorList); ok {
+		encoded := &jsonError{
+			Type:    errorListType,
+			Message: list.Error(),
+		}
+		for _, listErr := range list.Errors {
+			encoded.Causes = append(encoded.Causes, toJSONError(listErr, withStack))
+		}
+		return encoded
+	}
+	encoded := &jsonError{
+		Message: Redact(err.Error()),
+		Type:    reflect.TypeOf(err).String(),
+	}
+	if foreign, ok := err.(*ForeignError); ok {
+		encoded.Type = foreign.Type
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		encoded.Cause = toJSONError(cause, withStack)
+	}
+	return encoded
+}
+
+// fromJSONError restores an error of the chain from its JSON representation.
+func fromJSONError(decoded *jsonError) (error, error) {
+	var cause error
+	if decoded.Cause != nil {
+		restored, err := fromJSONError(decoded.Cause)
+		if err != nil {
+			return nil, err
+		}
+		cause = restored
+	}
+	if decoded.Code == "" && decoded.Type == errorListType {
+		list := &ErrorList{}
+		for _, listErr := range decoded.Causes {
+			restored, err := fromJSONError(listErr)
+			if err != nil {
+				return nil, err
+			}
+			list.Errors = append(list.Errors, restored)
+		}
+		return list, nil
+	}
+	if decoded.Code == "" {
+		return &ForeignError{Type: decoded.Type, Msg: decoded.Message, From: cause}, nil
+	}
+	code, exists := FromStringCode[decoded.Code]
+	if !exists {
+		return nil, fmt.Errorf("unknown error code %q", decoded.Code)
+	}
+	extended := &ExtendedError{
+		Code:   code,
+		Msg:    decoded.Message,
+		From:   cause,
+		fields: decoded.Fields,
+	}
+	if decoded.RetryAfter != "" {
+		retryAfter, err := time.ParseDuration(decoded.RetryAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay %q: %w", decoded.RetryAfter, err)
+		}
+		extended.retryAfter = retryAfter
+	}
+	for _, detail := range decoded.Details {
+		extended.details = append(extended.details, &anypb.Any{TypeUrl: detail.TypeURL, Value: detail.Value})
+	}
+	extended.setFrames(decoded.Stack)
+	return extended, nil
+}
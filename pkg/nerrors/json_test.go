@@ -0,0 +1,93 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var _ = ginkgo.Describe("Handler test on JSON marshalling", func() {
+	ginkgo.It("writes the code as its name and includes the chain", func() {
+		err := NewInternalErrorFrom(errors.New("standard error"), "internal error")
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+
+		decoded := make(map[string]interface{})
+		gomega.Expect(json.Unmarshal(raw, &decoded)).Should(gomega.Succeed())
+		gomega.Expect(decoded["code"]).Should(gomega.Equal("Internal"))
+		gomega.Expect(decoded["message"]).Should(gomega.Equal("internal error"))
+		gomega.Expect(decoded["stack"]).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(decoded["cause"]).Should(gomega.Equal(map[string]interface{}{
+			"type":    "*errors.errorString",
+			"message": "standard error",
+		}))
+	})
+	ginkgo.It("restores a chain of extended errors", func() {
+		err := NewInternalErrorFrom(NewAbortedErrorFrom(NewNotFoundError("not found"), "aborted"), "internal error")
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal(raw, restored)).Should(gomega.Succeed())
+		gomega.Expect(restored).Should(gomega.Equal(err))
+	})
+	ginkgo.It("restores foreign causes with their type", func() {
+		err := NewInternalErrorFrom(fmt.Errorf("wrapping: %w", NewNotFoundError("not found")), "internal error")
+		raw, mErr := json.Marshal(struct {
+			Err *ExtendedError `json:"err"`
+		}{Err: err})
+		gomega.Expect(mErr).Should(gomega.Succeed())
+
+		restored := struct {
+			Err *ExtendedError `json:"err"`
+		}{}
+		gomega.Expect(json.Unmarshal(raw, &restored)).Should(gomega.Succeed())
+		gomega.Expect(restored.Err.String()).Should(gomega.Equal(err.String()))
+		foreign, ok := restored.Err.From.(*ForeignError)
+		gomega.Expect(ok).Should(gomega.BeTrue())
+		gomega.Expect(foreign.Type).Should(gomega.Equal("*fmt.wrapError"))
+		gomega.Expect(foreign.From).Should(gomega.Equal(errors.Unwrap(err.From)))
+		gomega.Expect(errors.Is(restored.Err, ErrNotFound)).Should(gomega.BeTrue())
+
+		again, mErr := json.Marshal(restored)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+		gomega.Expect(again).Should(gomega.MatchJSON(raw))
+	})
+	ginkgo.It("carries the retry delay", func() {
+		err := NewInternalErrorFrom(NewUnavailableError("busy").WithRetryAfter(1500*time.Millisecond), "internal error")
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+		gomega.Expect(string(raw)).Should(gomega.ContainSubstring(`"retry_after":"1.5s"`))
+
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal(raw, restored)).Should(gomega.Succeed())
+		gomega.Expect(restored).Should(gomega.Equal(err))
+		gomega.Expect(restored.RetryAfter()).Should(gomega.Equal(1500 * time.Millisecond))
+		gomega.Expect(json.Unmarshal([]byte(`{"code":"Unavailable","message":"busy","retry_after":"soon"}`), restored)).ShouldNot(gomega.Succeed())
+	})
+	ginkgo.It("carries the details, including the ones of unknown types", func() {
+		unknown := &anypb.Any{TypeUrl: "type.googleapis.com/example.v1.Unknown", Value: []byte{0x0a, 0x02, 'o', 'k'}}
+		err := NewInvalidArgumentError("invalid app").
+			WithDetails(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name"}}})
+		err.details = append(err.details, unknown)
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal(raw, restored)).Should(gomega.Succeed())
+		gomega.Expect(restored.Details()).Should(gomega.HaveLen(2))
+		gomega.Expect(proto.Equal(restored.Details()[1], unknown)).Should(gomega.BeTrue())
+		gomega.Expect(restored.BadRequest().FieldViolations[0].Field).Should(gomega.Equal("name"))
+	})
+	ginkgo.It("fails on unknown codes", func() {
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal([]byte(`{"code":"Teapot","message":"short and stout"}`), restored)).ShouldNot(gomega.Succeed())
+	})
+})
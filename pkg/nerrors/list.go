@@ -0,0 +1,110 @@
+package nerrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// codePrecedence with the order used to pick the code of an ErrorList. The first code of this list found among the
+// aggregated errors is the code of the list. Codes related to failures of the server or its dependencies come
+// first, followed by the ones related to the caller, so a single internal failure is never hidden by validation
+// errors.
+var codePrecedence = []ErrorCode{
+	DataLoss, Internal, Unknown, Unavailable, DeadlineExceeded, Canceled, ResourceExhausted, Aborted, Unimplemented,
+	Unauthenticated, PermissionDenied, FailedPrecondition, NotFound, AlreadyExists, OutOfRange, InvalidArgument, OK,
+}
+
+// ErrorList aggregates several independent errors, for example the ones produced by a validation or a fan-out. Once
+// sent through gRPC, a list is received as the cause of an extended error with the code of the list.
+type ErrorList struct {
+	// Errors with the aggregated errors.
+	Errors []error
+}
+
+// Join aggregates the given errors into an ErrorList, discarding the nil ones. It returns nil if all the errors
+// are nil.
+func Join(errs ...error) error {
+	list := &ErrorList{}
+	for _, err := range errs {
+		if err != nil {
+			list.Errors = append(list.Errors, err)
+		}
+	}
+	if len(list.Errors) == 0 {
+		return nil
+	}
+	return list
+}
+
+// Error method to implement error interface
+func (el *ErrorList) Error() string {
+	messages := make([]string, len(el.Errors))
+	for i, err := range el.Errors {
+		messages[i] = err.Error()
+	}
+	return Redact(strings.Join(messages, "; "))
+}
+
+// Unwrap returns the aggregated errors so errors.Is and errors.As can inspect every branch.
+func (el *ErrorList) Unwrap() []error {
+	return el.Errors
+}
+
+// Code returns the code of the list, which is the code of the aggregated errors with the highest precedence. Codes
+// related to failures of the server (DataLoss, Internal, Unknown, Unavailable, DeadlineExceeded) take precedence over
+// the ones related to the caller (Unauthenticated, PermissionDenied, FailedPrecondition, NotFound, AlreadyExists,
+// OutOfRange, InvalidArgument).
+func (el *ErrorList) Code() ErrorCode {
+	found := make(map[ErrorCode]bool, len(el.Errors))
+	for _, err := range el.Errors {
+		found[CodeOf(err)] = true
+	}
+	for _, code := range codePrecedence {
+		if found[code] {
+			return code
+		}
+	}
+	return Unknown
+}
+
+// summary returns a short description of the list.
+func (el *ErrorList) summary() string {
+	return fmt.Sprintf("%d errors occurred", len(el.Errors))
+}
+
+// StackTraceToString renders the stack traces of the aggregated errors as a tree.
+func (el *ErrorList) StackTraceToString() string {
+	return el.stackTraceToString(nil)
+}
+
+// stackTraceToString renders the stack traces of the aggregated errors as a tree, omitting the frames shared with the
+// enclosing stack trace of the error caused by the list.
+func (el *ErrorList) stackTraceToString(enclosing StackTrace) string {
+	traces := el.summary() + "\n"
+	for i, err := range el.Errors {
+		first, rest := "├── ", "│   "
+		if i == len(el.Errors)-1 {
+			first, rest = "└── ", "    "
+		}
+		lines := strings.Split(strings.TrimSuffix(branchStackTrace(err, enclosing), "\n"), "\n")
+		for j, line := range lines {
+			if j == 0 {
+				traces += first + line + "\n"
+			} else {
+				traces += rest + line + "\n"
+			}
+		}
+	}
+	return traces
+}
+
+// branchStackTrace returns the stack trace of an aggregated error.
+func branchStackTrace(err error, enclosing StackTrace) string {
+	switch e := err.(type) {
+	case *ExtendedError:
+		return e.stackTraceToString(enclosing)
+	case *ErrorList:
+		return e.stackTraceToString(enclosing)
+	}
+	return Redact(err.Error()) + "\n" + " <stack trace no available>"
+}
@@ -0,0 +1,123 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = ginkgo.Describe("Handler test on error lists", func() {
+	ginkgo.It("discards nil errors", func() {
+		gomega.Expect(Join()).Should(gomega.BeNil())
+		gomega.Expect(Join(nil, nil)).Should(gomega.BeNil())
+		list := Join(nil, NewNotFoundError("not found"))
+		gomega.Expect(list.(*ErrorList).Errors).Should(gomega.HaveLen(1))
+	})
+	ginkgo.It("picks the code with the highest precedence", func() {
+		validation := Join(NewInvalidArgumentError("name is empty"), NewOutOfRangeError("replicas out of range"))
+		gomega.Expect(CodeOf(validation)).Should(gomega.Equal(OutOfRange))
+		gomega.Expect(FromError(validation).Code).Should(gomega.Equal(OutOfRange))
+
+		fanOut := Join(NewNotFoundError("not found"), fmt.Errorf("standard error"), NewUnavailableError("unavailable"))
+		gomega.Expect(CodeOf(fanOut)).Should(gomega.Equal(Unknown))
+		gomega.Expect(CodeOf(Join(NewNotFoundError("not found"), NewInternalError("internal")))).Should(gomega.Equal(Internal))
+	})
+	ginkgo.It("supports errors.Is and HasCode on every branch", func() {
+		err := NewAbortedErrorFrom(Join(NewInvalidArgumentError("name is empty"),
+			NewInternalErrorFrom(status.Error(codes.NotFound, "not found"), "internal")), "aborted")
+		gomega.Expect(errors.Is(err, ErrInvalidArgument)).Should(gomega.BeTrue())
+		gomega.Expect(errors.Is(err, ErrInternal)).Should(gomega.BeTrue())
+		gomega.Expect(errors.Is(err, ErrAlreadyExists)).Should(gomega.BeFalse())
+		gomega.Expect(HasCode(err, InvalidArgument)).Should(gomega.BeTrue())
+		gomega.Expect(HasCode(err, NotFound)).Should(gomega.BeTrue())
+		gomega.Expect(HasCode(err, AlreadyExists)).Should(gomega.BeFalse())
+	})
+	ginkgo.It("renders the stack traces as a tree", func() {
+		err := NewAbortedErrorFrom(Join(NewInvalidArgumentError("name is empty"), fmt.Errorf("standard error")), "aborted")
+		trace := err.StackTraceToString()
+		gomega.Expect(trace).Should(gomega.ContainSubstring("Caused by 2 errors occurred\n├── [InvalidArgument] name is empty\n│   "))
+		gomega.Expect(trace).Should(gomega.ContainSubstring("\n└── standard error\n     <stack trace no available>\n"))
+		gomega.Expect(strings.Count(trace, "\n├── ")).Should(gomega.Equal(1))
+	})
+	ginkgo.It("carries every branch through gRPC", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{FrameDetails: true})
+		err := FromError(Join(
+			NewInvalidArgumentError("name is empty"),
+			NewNotFoundErrorFrom(NewUnavailableError("unavailable"), "not found"),
+			Join(NewAlreadyExistsError("exists"), NewOutOfRangeError("out of range")),
+		))
+		gomega.Expect(err.Code).Should(gomega.Equal(NotFound))
+		converted := FromGRPC(err.ToGRPC())
+		gomega.Expect(converted.Code).Should(gomega.Equal(err.Code))
+		gomega.Expect(converted.Msg).Should(gomega.Equal(err.Msg))
+		list, ok := converted.From.(*ErrorList)
+		gomega.Expect(ok).Should(gomega.BeTrue())
+		gomega.Expect(list.Errors).Should(gomega.HaveLen(3))
+		gomega.Expect(list.Errors[0]).Should(gomega.Equal(err.From.(*ErrorList).Errors[0]))
+		gomega.Expect(list.Errors[1]).Should(gomega.Equal(err.From.(*ErrorList).Errors[1]))
+		nested := FromError(list.Errors[2])
+		gomega.Expect(nested.Code).Should(gomega.Equal(AlreadyExists))
+		gomega.Expect(nested.From.(*ErrorList).Errors).Should(gomega.HaveLen(2))
+	})
+	ginkgo.It("decodes each frame once when several errors reference it", func() {
+		details := make([]interface{}, 0)
+		for i := 0; i < 24; i++ {
+			details = append(details, &nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode_INTERNAL, Message: "fan-in",
+				CauseIndex: -1, CauseIndices: []int32{int32(i + 1), int32(i + 1)}})
+		}
+		details = append(details, &nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode_NOT_FOUND, Message: "not found", CauseIndex: -1})
+		decoded := ExtendedErrorFromDetail(details)
+		count := 0
+		var visit func(err error)
+		visit = func(err error) {
+			switch current := err.(type) {
+			case *ExtendedError:
+				count++
+				visit(current.From)
+			case *ErrorList:
+				for _, listErr := range current.Errors {
+					visit(listErr)
+				}
+			}
+		}
+		visit(decoded)
+		gomega.Expect(count).Should(gomega.Equal(len(details)))
+		gomega.Expect(errors.Is(decoded, NotFound)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("caps the number of decoded frames", func() {
+		details := make([]interface{}, 0)
+		for i := 0; i < 2*maxDecodedFrames; i++ {
+			details = append(details, &nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode_INTERNAL, Message: "link", CauseIndex: int32(i + 1)})
+		}
+		count := 0
+		for current := ExtendedErrorFromDetail(details); current != nil; current, _ = current.From.(*ExtendedError) {
+			count++
+		}
+		gomega.Expect(count).Should(gomega.Equal(maxDecodedFrames))
+	})
+	ginkgo.It("carries every branch through JSON", func() {
+		err := NewAbortedErrorFrom(Join(NewInvalidArgumentError("name is empty"), NewNotFoundError("not found")), "aborted")
+		raw, mErr := json.Marshal(err)
+		gomega.Expect(mErr).Should(gomega.Succeed())
+		restored := &ExtendedError{}
+		gomega.Expect(json.Unmarshal(raw, restored)).Should(gomega.Succeed())
+		gomega.Expect(restored).Should(gomega.Equal(err))
+	})
+	ginkgo.It("summarises the list in HTTP errors", func() {
+		body := FromError(Join(NewInvalidArgumentError("name is empty"), NewNotFoundError("not found"))).ToHTTPError()
+		gomega.Expect(body).Should(gomega.Equal(&HTTPError{
+			Code:    "NotFound",
+			Message: "2 errors occurred",
+			Causes:  []HTTPErrorCause{{Code: "NotFound", Message: "[InvalidArgument] name is empty; [NotFound] not found"}},
+		}))
+	})
+})
@@ -0,0 +1,93 @@
+package nerrors
+
+// LogObject adapts an error to the structured loggers. The error is written as an object with the same keys as its
+// JSON representation: code, type, message, fields, stack, cause and causes. Messages and fields are redacted. The
+// slog adapter is part of this package, and the ones of zerolog and zap are in the zerologx and zapx packages.
+type LogObject struct {
+	// Err with the error to write.
+	Err error
+	// StackTrace writes the stack frames of the chain.
+	StackTrace bool
+}
+
+// NewLogObject creates a LogObject for the error. The stack frames are only written if withStackTrace is set.
+func NewLogObject(err error, withStackTrace bool) LogObject {
+	return LogObject{Err: err, StackTrace: withStackTrace}
+}
+
+// LogEntry with the representation of an error of the chain written to the logs, walked by the adapters of the
+// structured loggers.
+type LogEntry struct {
+	// Code with the name of the error code, empty for the errors that are not extended errors.
+	Code string
+	// Type with the name of the go type of the errors that are not extended errors.
+	Type string
+	// Message with the redacted message of the error.
+	Message string
+	// Fields with the redacted fields of the error.
+	Fields map[string]interface{}
+	// Stack with the stack frames of the error, only set if they are requested.
+	Stack StackTrace
+	// Cause with the error wrapped by this one.
+	Cause *LogEntry
+	// Causes with the errors aggregated by an ErrorList.
+	Causes []*LogEntry
+}
+
+// Entry returns the representation of the error written to the logs, or nil if there is no error.
+func (lo LogObject) Entry() *LogEntry {
+	if lo.Err == nil {
+		return nil
+	}
+	return toLogEntry(toJSONError(lo.Err, lo.StackTrace))
+}
+
+// toLogEntry converts the JSON representation of an error of the chain into its log entry.
+func toLogEntry(encoded *jsonError) *LogEntry {
+	entry := &LogEntry{
+		Code:    encoded.Code,
+		Type:    encoded.Type,
+		Message: encoded.Message,
+		Fields:  encoded.Fields,
+		Stack:   encoded.Stack,
+	}
+	if encoded.Cause != nil {
+		entry.Cause = toLogEntry(encoded.Cause)
+	}
+	for _, cause := range encoded.Causes {
+		entry.Causes = append(entry.Causes, toLogEntry(cause))
+	}
+	return entry
+}
+
+// logObject returns the LogObject used when the extended error is written directly, without stack frames.
+func (ee *ExtendedError) logObject() LogObject {
+	if ee == nil {
+		return LogObject{}
+	}
+	return LogObject{Err: ee}
+}
+
+// Severity with the severity of the errors with a given code, mapped to the levels of each logger.
+type Severity int
+
+const (
+	// SeverityInfo for the errors that are expected, such as cancellations.
+	SeverityInfo Severity = iota
+	// SeverityWarn for the errors caused by the client.
+	SeverityWarn
+	// SeverityError for the failures of the server.
+	SeverityError
+)
+
+// SeverityOf returns the severity used to log an error. Cancellations are expected and logged as information, errors
+// caused by the client as warnings, and failures of the server as errors.
+func SeverityOf(err error) Severity {
+	switch CodeOf(err) {
+	case OK, Canceled:
+		return SeverityInfo
+	case InvalidArgument, NotFound, AlreadyExists, PermissionDenied, FailedPrecondition, OutOfRange, Unauthenticated:
+		return SeverityWarn
+	}
+	return SeverityError
+}
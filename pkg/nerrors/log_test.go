@@ -0,0 +1,48 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// decodeLogLine decodes a JSON line written by a logger.
+func decodeLogLine(line []byte) map[string]interface{} {
+	decoded := map[string]interface{}{}
+	gomega.Expect(json.Unmarshal(line, &decoded)).Should(gomega.Succeed())
+	return decoded
+}
+
+var _ = ginkgo.Describe("Handler test on structured logging", func() {
+	ginkgo.It("builds the log entry of the error chain", func() {
+		err := NewInternalErrorFrom(Join(NewNotFoundError("image not found"), errors.New("raw failure")),
+			"cannot deploy john@example.com").WithField("app", "web")
+		gomega.Expect(NewLogObject(err, false).Entry()).Should(gomega.Equal(&LogEntry{
+			Code:    "Internal",
+			Message: "cannot deploy [REDACTED]",
+			Fields:  map[string]interface{}{"app": "web"},
+			Cause: &LogEntry{
+				Type:    "*nerrors.ErrorList",
+				Message: "[NotFound] image not found; raw failure",
+				Causes: []*LogEntry{
+					{Code: "NotFound", Message: "image not found"},
+					{Type: "*errors.errorString", Message: "raw failure"},
+				},
+			},
+		}))
+	})
+	ginkgo.It("adds the stack frames on request", func() {
+		err := NewInternalError("internal")
+		gomega.Expect(NewLogObject(err, true).Entry().Stack).Should(gomega.Equal(err.Frames()))
+		gomega.Expect(NewLogObject(nil, true).Entry()).Should(gomega.BeNil())
+	})
+	ginkgo.It("picks the severity from the code", func() {
+		gomega.Expect(SeverityOf(NewCanceledError("canceled"))).Should(gomega.Equal(SeverityInfo))
+		gomega.Expect(SeverityOf(nil)).Should(gomega.Equal(SeverityInfo))
+		gomega.Expect(SeverityOf(NewNotFoundError("not found"))).Should(gomega.Equal(SeverityWarn))
+		gomega.Expect(SeverityOf(NewInternalError("internal"))).Should(gomega.Equal(SeverityError))
+		gomega.Expect(SeverityOf(errors.New("raw failure"))).Should(gomega.Equal(SeverityError))
+	})
+})
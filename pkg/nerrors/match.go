@@ -0,0 +1,61 @@
+package nerrors
+
+import (
+	"errors"
+)
+
+// Is method to support errors.Is. An extended error matches any ErrorCode, sentinel error or extended error with
+// the same code. A nil extended error matches nothing.
+func (ee *ExtendedError) Is(target error) bool {
+	if ee == nil {
+		return false
+	}
+	switch t := target.(type) {
+	case ErrorCode:
+		return ee.Code == t
+	case *ExtendedError:
+		return t != nil && ee.Code == t.Code
+	}
+	return false
+}
+
+// HasCode checks if any error of the chain has the given code, including every branch of the aggregated errors.
+// Besides extended errors, the code of gRPC status errors is also considered.
+func HasCode(err error, code ErrorCode) bool {
+	if err == nil {
+		return false
+	}
+	if linkCode, ok := codeOfLink(err); ok && linkCode == code {
+		return true
+	}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, branch := range multi.Unwrap() {
+			if HasCode(branch, code) {
+				return true
+			}
+		}
+		return false
+	}
+	return HasCode(errors.Unwrap(err), code)
+}
+
+// CodeOf returns the code of the first error of the chain with a known code, using the same rules as FromError.
+// It returns OK for nil errors, and Unknown if the code is not recognised.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return OK
+	}
+	code, _ := classify(err)
+	return code
+}
+
+// codeOfLink returns the code of a single error of the chain, without unwrapping it.
+func codeOfLink(err error) (ErrorCode, bool) {
+	switch e := err.(type) {
+	case *ExtendedError:
+		return e.Code, true
+	case grpcStatus:
+		return FromGRPCCode[e.GRPCStatus().Code()], true
+	}
+	return Unknown, false
+}
@@ -0,0 +1,62 @@
+package nerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = ginkgo.Describe("Handler test on error matching", func() {
+	ginkgo.Context("errors.Is", func() {
+		ginkgo.It("matches codes anywhere in the chain", func() {
+			err := NewInternalErrorFrom(fmt.Errorf("wrapping: %w", NewNotFoundError("not found")), "internal error")
+			gomega.Expect(errors.Is(err, Internal)).Should(gomega.BeTrue())
+			gomega.Expect(errors.Is(err, NotFound)).Should(gomega.BeTrue())
+			gomega.Expect(errors.Is(err, ErrNotFound)).Should(gomega.BeTrue())
+			gomega.Expect(errors.Is(err, NewNotFoundError("other"))).Should(gomega.BeTrue())
+			gomega.Expect(errors.Is(err, AlreadyExists)).Should(gomega.BeFalse())
+			gomega.Expect(errors.Is(fmt.Errorf("standard error"), Unknown)).Should(gomega.BeFalse())
+		})
+		ginkgo.It("matches codes on chains that crossed gRPC", func() {
+			err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal error")
+			converted := FromGRPC(err.ToGRPC())
+			gomega.Expect(errors.Is(converted, ErrNotFound)).Should(gomega.BeTrue())
+			gomega.Expect(errors.Is(converted, ErrPermissionDenied)).Should(gomega.BeFalse())
+		})
+		ginkgo.It("does not match nil extended errors", func() {
+			var extended *ExtendedError
+			gomega.Expect(errors.Is(extended, ErrNotFound)).Should(gomega.BeFalse())
+			gomega.Expect(errors.Is(fmt.Errorf("wrapping: %w", extended), NotFound)).Should(gomega.BeFalse())
+		})
+		ginkgo.It("supports errors.As", func() {
+			err := fmt.Errorf("wrapping: %w", NewNotFoundError("not found"))
+			var extended *ExtendedError
+			gomega.Expect(errors.As(err, &extended)).Should(gomega.BeTrue())
+			gomega.Expect(extended.Code).Should(gomega.Equal(NotFound))
+		})
+	})
+	ginkgo.Context("HasCode", func() {
+		ginkgo.It("searches the whole chain", func() {
+			err := NewInternalErrorFrom(NewAbortedErrorFrom(status.Error(codes.NotFound, "not found"), "aborted"), "internal")
+			gomega.Expect(HasCode(err, Internal)).Should(gomega.BeTrue())
+			gomega.Expect(HasCode(err, Aborted)).Should(gomega.BeTrue())
+			gomega.Expect(HasCode(err, NotFound)).Should(gomega.BeTrue())
+			gomega.Expect(HasCode(err, Unavailable)).Should(gomega.BeFalse())
+			gomega.Expect(HasCode(nil, OK)).Should(gomega.BeFalse())
+		})
+	})
+	ginkgo.Context("CodeOf", func() {
+		ginkgo.It("returns the first known code", func() {
+			gomega.Expect(CodeOf(nil)).Should(gomega.Equal(OK))
+			gomega.Expect(CodeOf(fmt.Errorf("wrapping: %w", NewNotFoundError("not found")))).Should(gomega.Equal(NotFound))
+			gomega.Expect(CodeOf(status.Error(codes.Aborted, "aborted"))).Should(gomega.Equal(Aborted))
+			gomega.Expect(CodeOf(context.Canceled)).Should(gomega.Equal(Canceled))
+			gomega.Expect(CodeOf(fmt.Errorf("standard error"))).Should(gomega.Equal(Unknown))
+		})
+	})
+})
@@ -1,13 +1,19 @@
 package nerrors
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/napptive/grpc-common-go"
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/anypb"
 	"reflect"
-	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 // ExtendedError with an extended golang error
@@ -18,28 +24,46 @@ type ExtendedError struct {
 	Msg string
 	// From links with the parent error if any.
 	From error
-	// StackTrace related to where the error happened in the code base.
+	// StackTrace related to where the error happened in the code base, with entries formatted as
+	// "file:line - function\n". It is kept for compatibility: the stack trace of the errors created by this library
+	// is captured as program counters and resolved when the error is created to fill the field, unless
+	// Config.LazyStackTrace is set to resolve them into frames only when needed. Frames and StackEntries read the
+	// stack trace in both cases.
 	StackTrace []string
+	// fields with structured key/value information about the error.
+	fields map[string]interface{}
+	// retryAfter with the delay the caller should wait before retrying.
+	retryAfter time.Duration
+	// details with the protobuf details attached to the error, sent after the error frames.
+	details []*anypb.Any
+	// detailsErr with the error found encoding the attached details, reported when the error is sent through gRPC.
+	detailsErr error
+	// frames with the resolved stack trace.
+	frames StackTrace
+	// pcs with the program counters captured when the error was created, pending to be resolved into the stack trace.
+	pcs []uintptr
+	// stackMutex with the lock of the lazy resolution of the program counters, only set if they are resolved lazily.
+	stackMutex *sync.Mutex
 }
 
 // NewExtendedError generic method to create an extended error
 func NewExtendedError(code ErrorCode, format string, a ...interface{}) *ExtendedError {
-	return &ExtendedError{
-		Code:       code,
-		Msg:        formatMsg(format, a...),
-		StackTrace: getStackTrace(),
-	}
+	return (&ExtendedError{
+		Code: code,
+		Msg:  formatMsg(format, a...),
+		pcs:  getStackTrace(code),
+	}).fillStackTrace()
 }
 
 // NewExtendedError From generic method to create an extended error from another caused by another one
 func NewExtendedErrorFrom(code ErrorCode, err error,
 	format string, a ...interface{}) *ExtendedError {
-	return &ExtendedError{
-		Code:       code,
-		Msg:        formatMsg(format, a...),
-		From:       err,
-		StackTrace: getStackTrace(),
-	}
+	return (&ExtendedError{
+		Code: code,
+		Msg:  formatMsg(format, a...),
+		From: err,
+		pcs:  getStackTrace(code),
+	}).fillStackTrace()
 }
 
 // Error method to implement error interface
@@ -49,91 +73,179 @@ func (ee *ExtendedError) Error() string {
 
 func (ee *ExtendedError) String() string {
 	msg := ee.ShortString()
-	if ee.From != nil {
-		return fmt.Sprintf("%s caused by %s", msg, ee.From.Error())
+	if ee.From != nil && ee.Msg != "" {
+		return fmt.Sprintf("%s caused by %s", msg, Redact(ee.From.Error()))
 	}
 	return msg
 }
 
 func (ee *ExtendedError) ShortString() string {
-	return fmt.Sprintf("[%s] %s", ee.Code.String(), ee.Msg)
+	return fmt.Sprintf("[%s] %s", ee.Code.String(), Redact(ee.message()))
+}
+
+// message returns the message of the error. The errors converted by FromError have no message of their own, so the
+// message of the original error kept as the cause is returned instead.
+func (ee *ExtendedError) message() string {
+	if ee.Msg != "" || ee.From == nil {
+		return ee.Msg
+	}
+	if se, ok := ee.From.(grpcStatus); ok {
+		return se.GRPCStatus().Message()
+	}
+	return ee.From.Error()
 }
 
 // Unwrap method to implement Wrapper interface (provides context around another error)
 func (ee *ExtendedError) Unwrap() error {
+	if ee == nil {
+		return nil
+	}
 	return ee.From
 }
 
-// StackTraceToString loops through error chain showing stack trace
+// StackTraceToString loops through error chain showing stack trace. The frames a cause shares with the error it
+// caused are replaced by "... N more".
 func (ee *ExtendedError) StackTraceToString() string {
+	return ee.stackTraceToString(nil)
+}
+
+// stackTraceToString returns the stack trace of the error chain omitting the frames shared with the enclosing stack
+// trace of the error caused by this one.
+func (ee *ExtendedError) stackTraceToString(enclosing StackTrace) string {
 	if ee == nil {
 		return ""
 	}
-	traces := ee.ShortString() + "\n" + strings.Join(ee.StackTrace, "")
-	if ee.From != nil {
+	frames := ee.Frames()
+	shared := frames.sharedFrames(enclosing, sameLocation)
+	traces := ee.ShortString() + formatFields(redactFields(ee.fields)) + "\n" + frames[:len(frames)-shared].String()
+	if shared > 0 {
+		traces += fmt.Sprintf("... %d more\n", shared)
+	}
+	if from := ee.traceCause(); from != nil {
 		traces += "Caused by "
 		var pp *ExtendedError
-		if reflect.TypeOf(ee.From) == reflect.TypeOf(pp) {
-			traces += ee.From.(*ExtendedError).StackTraceToString()
+		if reflect.TypeOf(from) == reflect.TypeOf(pp) {
+			traces += from.(*ExtendedError).stackTraceToString(frames)
+		} else if list, ok := from.(*ErrorList); ok {
+			traces += list.stackTraceToString(frames)
 		} else {
-			traces += ee.From.Error() + "\n" + " <stack trace no available>"
+			traces += Redact(from.Error()) + "\n" + " <stack trace no available>"
 		}
 	}
 	return traces
 
 }
 
-// getStackTrace get the stack trace when an error occurs
-func getStackTrace() []string {
-	buf := make([]uintptr, 32)
-	callers := runtime.Callers(2, buf)
-	stackTrace := make([]string, callers)
-	for i := 0; i < callers; i++ {
-		frames := runtime.FuncForPC(buf[i])
-		filePath, line := frames.FileLine(buf[i])
-		stackTrace[i] = fmt.Sprintf("%s:%d - %s\n", filePath, line, frames.Name())
+// traceCause returns the cause shown in the stack trace. An error without its own message shows the message of its
+// foreign cause, so only the chain wrapped by that cause is shown.
+func (ee *ExtendedError) traceCause() error {
+	if ee.Msg != "" || ee.From == nil {
+		return ee.From
 	}
-	return stackTrace
-}
-
-// getDetails converts a Extended Message into a list of Proto Message
-// The detail is Code: ... - Msg: ...
-func (ee *ExtendedError) getDetails(list []protoiface.MessageV1) []protoiface.MessageV1 {
-
-	debugInfo := &grpc_common_go.ErrorDetails{
-		StackEntries: ee.StackTrace,
-		Detail:       fmt.Sprintf("Code: %s - Msg: %s", ee.Code.String(), ee.Msg),
+	switch ee.From.(type) {
+	case *ExtendedError, *ErrorList:
+		return ee.From
+	}
+	return causeFromError(ee.From).From
+}
+
+// getDetails converts the error chain into a list of ErrorFrame details. The first detail is the outermost error,
+// and each detail links with the error that caused it through its cause index, or with the errors of an ErrorList
+// through its cause indices. The frames shared with the enclosing stack trace of the error caused by this one are
+// only counted.
+func (ee *ExtendedError) getDetails(list []*nerrorspb.ErrorFrame, enclosing StackTrace) []*nerrorspb.ErrorFrame {
+	frames := ee.Frames()
+	shared := frames.sharedFrames(enclosing, sameFrame)
+	frame := &nerrorspb.ErrorFrame{
+		Code:         nerrorspb.ErrorCode(ee.Code),
+		Message:      Redact(ee.Msg),
+		StackFrames:  toStackFrames(frames[:len(frames)-shared]),
+		CauseIndex:   -1,
+		Fields:       toFieldValues(redactFields(ee.fields)),
+		SharedFrames: int32(shared),
 	}
-	if ee.From == nil {
-		return append(list, debugInfo)
-	} else {
-		return append(FromError(ee.From).getDetails(list), debugInfo)
+	list = append(list, frame)
+	if errList, ok := ee.From.(*ErrorList); ok {
+		for _, err := range errList.Errors {
+			frame.CauseIndices = append(frame.CauseIndices, int32(len(list)))
+			list = causeFromError(err).getDetails(list, frames)
+		}
+	} else if ee.From != nil {
+		frame.CauseIndex = int32(len(list))
+		list = causeFromError(ee.From).getDetails(list, frames)
+	}
+	return list
+}
+
+// getLegacyDetails converts the error chain into the ErrorDetails sent by the older versions of the library, from the
+// innermost to the outermost error. Those versions only decode chains made of ErrorDetails, so the errors of an
+// ErrorList are sent as a single error with the summary message.
+func (ee *ExtendedError) getLegacyDetails() []protoiface.MessageV1 {
+	links := append([]*ExtendedError{ee}, linearCauses(ee.From)...)
+	details := make([]protoiface.MessageV1, 0, len(links))
+	for i := len(links) - 1; i >= 0; i-- {
+		details = append(details, &grpc_common_go.ErrorDetails{
+			StackEntries: links[i].StackEntries(),
+			Detail:       fmt.Sprintf("Code: %s - Msg: %s", links[i].Code.String(), Redact(links[i].Msg)),
+		})
 	}
+	return details
 }
 
-
-// TODO: in the next version, instead of use DebugInfo and compose a detail, we can implement our own protoiface.MessageV1
 // ToGRPC converts an extended error to a GrpcError
 func (ee *ExtendedError) ToGRPC() error {
-	code, exists := ToGRPCCode[ee.Code]
-	if !exists {
-		fmt.Printf("Code (%d - %s) does not exist\n", ee.Code, ee.Code.String())
+	st, err := ee.grpcStatus()
+	if err != nil {
+		fmt.Printf("Error converting error to GRPC: %s\n", err.Error())
 		return ee
 	}
+	return st.Err()
+}
 
-	st := status.New(code, ee.Msg)
+// GRPCStatus returns the gRPC status of the error with the whole error chain as details. This method is used by
+// grpc-go to convert extended errors without calling ToGRPC explicitly.
+func (ee *ExtendedError) GRPCStatus() *status.Status {
+	st, err := ee.grpcStatus()
+	if err != nil {
+		code, exists := ToGRPCCode[ee.Code]
+		if !exists {
+			code = codes.Unknown
+		}
+		return status.New(code, Redact(ee.message()))
+	}
+	return st
+}
 
-	// we create as many details as errors we have in the chain. This is the way to convert a GPRC to Extended Error again
-	details := make([]protoiface.MessageV1, 0)
-	allDetails := ee.getDetails(details)
+// grpcStatus builds the gRPC status of the error.
+func (ee *ExtendedError) grpcStatus() (*status.Status, error) {
+	code, exists := ToGRPCCode[ee.Code]
+	if !exists {
+		return nil, fmt.Errorf("code (%d - %s) does not exist", ee.Code, ee.Code.String())
+	}
 
-	complexSt, err := st.WithDetails(allDetails...)
+	st := status.New(code, Redact(ee.message()))
+	if !loadConfig().FrameDetails {
+		return st.WithDetails(ee.getLegacyDetails()...)
+	}
 
-	if err != nil {
-		fmt.Printf("Error converting error to GRPC: %s\n", err.Error())
-		return ee
+	// we create as many details as errors we have in the chain. This is the way to convert a GPRC to Extended Error again
+	details := make([]*nerrorspb.ErrorFrame, 0)
+	allDetails := ee.getDetails(details, nil)
+
+	// the retry delay and the attached details are added after the error frames so their indices are not altered.
+	trailing := make([]*anypb.Any, 0)
+	if retryInfo := ee.toRetryInfo(); retryInfo != nil {
+		encoded, err := anypb.New(retryInfo)
+		if err != nil {
+			return nil, err
+		}
+		trailing = append(trailing, encoded)
+	}
+	if err := ee.detailsError(); err != nil {
+		return nil, err
 	}
-	return complexSt.Err()
+	trailing = append(trailing, ee.Details()...)
+	return fitStatus(st, allDetails, loadConfig().MaxDetailsSize, trailing...)
 }
 
 // FromGRPC converts a GrpcError to an extended error
@@ -141,22 +253,107 @@ func FromGRPC(err error) *ExtendedError {
 	st := status.Convert(err)
 	code := st.Code()
 
-	if len(st.Details()) == 0 {
-		return &ExtendedError{
+	extended := ExtendedErrorFromDetail(st.Details())
+	if extended == nil {
+		return (&ExtendedError{
 			Code:       FromGRPCCode[code],
 			Msg:        st.Message(),
 			From:       nil,
-			StackTrace: getStackTrace(),
-		}
+			retryAfter: retryAfterFromDetails(st.Details()),
+			details:    extraDetails(st),
+			pcs:        getStackTrace(FromGRPCCode[code]),
+		}).fillStackTrace()
 	}
-
-	extended := ExtendedErrorFromDetail(st.Details())
 	extended.Code = FromGRPCCode[code]
+	extended.retryAfter = retryAfterFromDetails(st.Details())
+	extended.details = extraDetails(st)
 
 	return extended
 
 }
 
+// ExtendedErrorFromDetail create an extended error from the details of the grpc error. Details sent by older
+// versions of the library using ErrorDetails are also supported. Other details are ignored, and nil is returned if
+// there are no details of this library.
+func ExtendedErrorFromDetail(details []interface{}) *ExtendedError {
+	legacy := make([]interface{}, 0)
+	for index, detail := range details {
+		switch detail.(type) {
+		case *nerrorspb.ErrorFrame:
+			decoder := &frameDecoder{details: details, decoded: make(map[int]bool)}
+			return decoder.fromErrorFrame(index, nil)
+		case *grpc_common_go.ErrorDetails:
+			legacy = append(legacy, detail)
+		}
+	}
+	return fromLegacyDetails(legacy)
+}
+
+// maxDecodedFrames with the maximum number of error frames decoded from the details of a gRPC status. The frames
+// after it are dropped.
+const maxDecodedFrames = 1024
+
+// frameDecoder decodes the ErrorFrame details of a gRPC status into an error chain.
+type frameDecoder struct {
+	// details with the details of the status.
+	details []interface{}
+	// decoded with the positions of the frames already decoded. Each frame is decoded once, so malformed details
+	// that reference a frame from several errors cannot multiply the number of errors.
+	decoded map[int]bool
+}
+
+// fromErrorFrame creates the extended error described by the ErrorFrame in the given position of the details. The
+// shared frames are copied from the enclosing stack trace of the error caused by this one.
+func (fd *frameDecoder) fromErrorFrame(index int, enclosing StackTrace) *ExtendedError {
+	fd.decoded[index] = true
+	frame := fd.details[index].(*nerrorspb.ErrorFrame)
+	extended := &ExtendedError{
+		Code:   frameCode(frame.Code),
+		Msg:    frame.Message,
+		fields: fromFieldValues(frame.Fields),
+	}
+	frames := fromStackFrames(frame.StackFrames)
+	if shared := int(frame.SharedFrames); shared > 0 && shared <= len(enclosing) {
+		frames = append(frames, enclosing[len(enclosing)-shared:]...)
+	}
+	extended.setFrames(frames)
+	if len(frame.CauseIndices) > 0 {
+		list := &ErrorList{}
+		for _, cause := range frame.CauseIndices {
+			if causeErr := fd.fromCauseFrame(index, int(cause), frames); causeErr != nil {
+				list.Errors = append(list.Errors, causeErr)
+			}
+		}
+		extended.From = list
+	} else if causeErr := fd.fromCauseFrame(index, int(frame.CauseIndex), frames); causeErr != nil {
+		extended.From = causeErr
+	}
+	return extended
+}
+
+// frameCode returns the code of an ErrorFrame. The codes sent by the peer that are not defined in this library are
+// decoded as Unknown.
+func frameCode(code nerrorspb.ErrorCode) ErrorCode {
+	if _, exists := ToGRPCCode[ErrorCode(code)]; !exists {
+		return Unknown
+	}
+	return ErrorCode(code)
+}
+
+// fromCauseFrame creates the cause of the error in the given position of the details. It returns nil if the cause
+// is not a valid ErrorFrame placed after the error, if it was already decoded as the cause of another error, or if
+// the maximum number of frames was decoded. Causes placed after their error prevent loops, and decoding each frame
+// once bounds the size of the chain.
+func (fd *frameDecoder) fromCauseFrame(index int, cause int, enclosing StackTrace) *ExtendedError {
+	if cause <= index || cause >= len(fd.details) || fd.decoded[cause] || len(fd.decoded) >= maxDecodedFrames {
+		return nil
+	}
+	if _, ok := fd.details[cause].(*nerrorspb.ErrorFrame); !ok {
+		return nil
+	}
+	return fd.fromErrorFrame(cause, enclosing)
+}
+
 //
 // getCodeFromGRPCMsg try to get the error code and the message if the details has the format belong
 // Detail: fmt.Sprintf("Code: %s - Msg: %s", ee.Code.String(), ee.Msg),
@@ -178,54 +375,172 @@ func getCodeFromGRPCMsg(msg string) (string, ErrorCode) {
 	return msg, Unknown
 }
 
-// ExtendedErrorFromDetail create an extended error from the details of the grpc error
-func ExtendedErrorFromDetail(details []interface{}) *ExtendedError {
+// fromLegacyDetails creates an extended error from the ErrorDetails sent by older versions of the library, in which
+// the last detail is the outermost error and the code and message are encoded as "Code: ... - Msg: ...".
+func fromLegacyDetails(details []interface{}) *ExtendedError {
 	if len(details) == 0 {
 		return nil
 	}
 
-	if len(details) == 1 {
-		if e, ok := details[len(details)-1].(*grpc_common_go.ErrorDetails); ok {
-			info := e
-			stackTrace := info.StackEntries
-			msg, code := getCodeFromGRPCMsg(info.Detail)
-			return &ExtendedError{
-				Msg:        msg,
-				Code:       code,
-				From:       nil,
-				StackTrace: stackTrace,
-			}
-		}
-	} else {
-		if e, ok := details[len(details)-1].(*grpc_common_go.ErrorDetails); ok {
-			info := e
-			stackTrace := info.StackEntries
-			msg, code := getCodeFromGRPCMsg(info.Detail)
-			return &ExtendedError{
-				Msg:        msg,
-				Code:       code,
-				From:       ExtendedErrorFromDetail(details[0 : len(details)-1]),
-				StackTrace: stackTrace,
-			}
-		}
+	info, ok := details[len(details)-1].(*grpc_common_go.ErrorDetails)
+	if !ok {
+		return nil
 	}
-	return nil
+	msg, code := getCodeFromGRPCMsg(info.Detail)
+	extended := &ExtendedError{
+		Msg:        msg,
+		Code:       code,
+		From:       nil,
+		StackTrace: info.StackEntries,
+	}
+	if cause := fromLegacyDetails(details[0 : len(details)-1]); cause != nil {
+		extended.From = cause
+	}
+	return extended
+}
+
+// toStackFrames converts the frames of a stack trace into StackFrame messages.
+func toStackFrames(stackTrace StackTrace) []*nerrorspb.StackFrame {
+	frames := make([]*nerrorspb.StackFrame, 0, len(stackTrace))
+	for _, frame := range stackTrace {
+		frames = append(frames, &nerrorspb.StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     int64(frame.Line),
+			Package:  frame.Package,
+			Pc:       uint64(frame.PC),
+		})
+	}
+	return frames
+}
 
+// fromStackFrames converts StackFrame messages into the frames of a stack trace.
+func fromStackFrames(frames []*nerrorspb.StackFrame) StackTrace {
+	if len(frames) == 0 {
+		return nil
+	}
+	stackTrace := make(StackTrace, len(frames))
+	for i, frame := range frames {
+		stackTrace[i] = Frame{
+			Function: frame.Function,
+			Package:  frame.Package,
+			File:     frame.File,
+			Line:     int(frame.Line),
+			PC:       uintptr(frame.Pc),
+		}
+	}
+	return stackTrace
 }
 
-// FromError transforms a standard go error into an extended error
+// FromError transforms a standard go error into an extended error. The code is obtained from the first extended
+// error of the chain, gRPC status errors, context errors and the registered classifiers (see RegisterClassifier).
+// When the code is recognised, the original error is kept as the cause and the message is left empty, so it is not
+// printed twice.
 func FromError(err error) *ExtendedError {
+	if err == nil {
+		return nil
+	}
 
 	if e, ok := err.(*ExtendedError); ok {
 		return e
 	}
 
-	return &ExtendedError{
-		Code:       Unknown,
-		Msg:        err.Error(),
-		From:       nil,
-		StackTrace: getStackTrace(),
+	extended := &ExtendedError{
+		Code: Unknown,
+		Msg:  err.Error(),
+		From: nil,
+	}
+	if code, recognised := classify(err); recognised {
+		extended.Code = code
+		extended.Msg = ""
+		extended.From = err
+	}
+	extended.pcs = getStackTrace(extended.Code)
+	if list, ok := err.(*ErrorList); ok {
+		extended.Msg = list.summary()
+	}
+	return extended.fillStackTrace()
+}
+
+// grpcStatus is implemented by the errors that can be converted into a gRPC status.
+type grpcStatus interface {
+	GRPCStatus() *status.Status
+}
+
+// classify walks the error chain looking for a known error code. It returns false if the code is not recognised.
+func classify(err error) (ErrorCode, bool) {
+	if list, ok := err.(*ErrorList); ok {
+		return list.Code(), true
+	}
+	var extended *ExtendedError
+	if errors.As(err, &extended) {
+		return extended.Code, true
+	}
+	var se grpcStatus
+	if errors.As(err, &se) {
+		return FromGRPCCode[se.GRPCStatus().Code()], true
+	}
+	if errors.Is(err, context.Canceled) {
+		return Canceled, true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return DeadlineExceeded, true
+	}
+	return classifyWithRegistry(err)
+}
+
+// causeFromError converts the cause of an error into an extended error to be sent through gRPC. Unlike FromError,
+// the resulting error never links back to err, which keeps the chain finite.
+func causeFromError(err error) *ExtendedError {
+	if e, ok := err.(*ExtendedError); ok {
+		return e
+	}
+	if list, ok := err.(*ErrorList); ok {
+		return &ExtendedError{Code: list.Code(), Msg: list.summary(), From: list}
+	}
+	// gRPC errors received from other services carry their own chain.
+	if se, ok := err.(grpcStatus); ok {
+		st := se.GRPCStatus()
+		if extended := ExtendedErrorFromDetail(st.Details()); extended != nil {
+			extended.Code = FromGRPCCode[st.Code()]
+			return extended
+		}
+	}
+	code, _ := classify(err)
+	cause := &ExtendedError{
+		Code: code,
+		Msg:  err.Error(),
+	}
+	var extended *ExtendedError
+	if errors.As(err, &extended) {
+		cause.From = extended
+	}
+	return cause
+}
+
+// linearCauses returns the causes of an error, from the outermost to the innermost. An ErrorList ends the chain, and
+// it is returned as a single cause with the messages of all its errors.
+func linearCauses(err error) []*ExtendedError {
+	causes := make([]*ExtendedError, 0)
+	for err != nil {
+		if list, ok := err.(*ErrorList); ok {
+			return append(causes, &ExtendedError{Code: list.Code(), Msg: list.Error()})
+		}
+		extended := causeFromError(err)
+		causes = append(causes, extended)
+		err = extended.From
+	}
+	return causes
+}
+
+// causes returns the causes of the error as a flat list. An error without its own message shows the message of its
+// cause, so that cause is skipped instead of being repeated.
+func (ee *ExtendedError) causes() []*ExtendedError {
+	causes := linearCauses(ee.From)
+	if ee.Msg == "" && len(causes) > 0 {
+		return causes[1:]
 	}
+	return causes
 }
 
 // ---------------
@@ -1,10 +1,16 @@
 package nerrors
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/napptive/grpc-common-go"
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 	"testing"
+	"time"
 
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -118,9 +124,99 @@ var _ = ginkgo.Describe("Handler test on nerrors calls", func() {
 			gomega.Expect(converted).ShouldNot(gomega.BeNil())
 
 		})
+		ginkgo.It("encodes the error chain as ErrorFrame details", func() {
+			previous := GetConfig()
+			defer SetConfig(previous)
+			SetConfig(Config{FrameDetails: true})
+			err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal error")
+			details := status.Convert(err.ToGRPC()).Details()
+			gomega.Expect(details).Should(gomega.HaveLen(2))
+			outer, ok := details[0].(*nerrorspb.ErrorFrame)
+			gomega.Expect(ok).Should(gomega.BeTrue())
+			gomega.Expect(outer.Code).Should(gomega.Equal(nerrorspb.ErrorCode_INTERNAL))
+			gomega.Expect(outer.Message).Should(gomega.Equal("internal error"))
+			gomega.Expect(outer.StackFrames).ShouldNot(gomega.BeEmpty())
+			gomega.Expect(outer.StackFrames[0].Function).Should(gomega.ContainSubstring("nerrors"))
+			gomega.Expect(outer.StackFrames[0].Line).Should(gomega.BeNumerically(">", 0))
+			gomega.Expect(outer.CauseIndex).Should(gomega.Equal(int32(1)))
+			inner, ok := details[1].(*nerrorspb.ErrorFrame)
+			gomega.Expect(ok).Should(gomega.BeTrue())
+			gomega.Expect(inner.Code).Should(gomega.Equal(nerrorspb.ErrorCode_NOT_FOUND))
+			gomega.Expect(inner.CauseIndex).Should(gomega.Equal(int32(-1)))
+		})
+		ginkgo.It("can convert messages with the legacy separators", func() {
+			err := NewNotFoundErrorFrom(NewInternalError("Code: Internal - Msg: inner"), "Code: Aborted - Msg: outer")
+			converted := FromGRPC(err.ToGRPC())
+			gomega.Expect(converted).Should(gomega.Equal(err))
+		})
+		ginkgo.It("sends the details of older versions by default", func() {
+			err := NewInternalErrorFrom(NewNotFoundError("id was not found").WithField("id", 3), "internal error").WithRetryAfter(time.Second)
+			details := status.Convert(err.ToGRPC()).Details()
+			gomega.Expect(details).Should(gomega.HaveLen(2))
+			inner, ok := details[0].(*grpc_common_go.ErrorDetails)
+			gomega.Expect(ok).Should(gomega.BeTrue())
+			gomega.Expect(inner.Detail).Should(gomega.Equal("Code: NotFound - Msg: id was not found"))
+			outer, ok := details[1].(*grpc_common_go.ErrorDetails)
+			gomega.Expect(ok).Should(gomega.BeTrue())
+			gomega.Expect(outer.Detail).Should(gomega.Equal("Code: Internal - Msg: internal error"))
+			gomega.Expect(outer.StackEntries).Should(gomega.Equal(err.StackEntries()))
+
+			converted := FromGRPC(err.ToGRPC())
+			gomega.Expect(converted.Code).Should(gomega.Equal(Internal))
+			gomega.Expect(converted.Msg).Should(gomega.Equal("internal error"))
+			gomega.Expect(converted.From.(*ExtendedError).Code).Should(gomega.Equal(NotFound))
+		})
+		ginkgo.It("decodes the codes unknown to this version as Unknown", func() {
+			details := []interface{}{
+				&nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode_INTERNAL, Message: "internal", CauseIndex: 1},
+				&nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode(42), Message: "from the future", CauseIndex: -1},
+			}
+			converted := ExtendedErrorFromDetail(details)
+			gomega.Expect(converted.From.(*ExtendedError).Code).Should(gomega.Equal(Unknown))
+			gomega.Expect(converted.Error()).Should(gomega.ContainSubstring("[Unknown] from the future"))
+			gomega.Expect(ErrorCode(42).String()).Should(gomega.Equal("ErrorCode(42)"))
+			gomega.Expect(ErrorCode(-1).Error()).Should(gomega.Equal("ErrorCode(-1)"))
+		})
+	})
+	// GRPCStatus
+	ginkgo.Context("checking native conversions to GRPC", func() {
+		ginkgo.It("exposes the gRPC status of the error", func() {
+			err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal error")
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.Internal))
+			st, ok := status.FromError(err)
+			gomega.Expect(ok).Should(gomega.BeTrue())
+			gomega.Expect(st.Message()).Should(gomega.Equal("internal error"))
+			gomega.Expect(st.Details()).Should(gomega.HaveLen(2))
+			gomega.Expect(FromGRPC(err)).Should(gomega.Equal(err))
+		})
+		ginkgo.It("is converted by the gRPC server without interceptors", func() {
+			service := &failingHealthServer{err: NewNotFoundErrorFrom(fmt.Errorf("standard error"), "not found")}
+			server, conn := testServer(service, nil)
+			defer server.Stop()
+			defer conn.Close()
+
+			_, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			gomega.Expect(status.Code(err)).Should(gomega.Equal(codes.NotFound))
+			converted := FromGRPC(err)
+			gomega.Expect(converted.Msg).Should(gomega.Equal("not found"))
+			gomega.Expect(FromError(converted.From).Msg).Should(gomega.Equal("standard error"))
+		})
 	})
 	// FromGrpc
 	ginkgo.Context("checking conversions from GRPC", func() {
+		ginkgo.It("can convert the details of older versions", func() {
+			st, err := status.New(codes.Internal, "internal error").WithDetails(
+				&grpc_common_go.ErrorDetails{StackEntries: []string{"main.go:3 - main.main\n"}, Detail: "Code: NotFound - Msg: not found"},
+				&grpc_common_go.ErrorDetails{StackEntries: []string{"main.go:5 - main.main\n"}, Detail: "Code: Internal - Msg: internal error"})
+			gomega.Expect(err).Should(gomega.Succeed())
+			extended := FromGRPC(st.Err())
+			gomega.Expect(extended.Code).Should(gomega.Equal(Internal))
+			gomega.Expect(extended.Msg).Should(gomega.Equal("internal error"))
+			gomega.Expect(extended.StackTrace).Should(gomega.Equal([]string{"main.go:5 - main.main\n"}))
+			gomega.Expect(extended.From).ShouldNot(gomega.BeNil())
+			gomega.Expect(FromError(extended.From).Code).Should(gomega.Equal(NotFound))
+			gomega.Expect(FromError(extended.From).Msg).Should(gomega.Equal("not found"))
+		})
 		ginkgo.It("can convert from GRPC", func() {
 			err := status.Error(codes.NotFound, "id was not found")
 			extended := FromGRPC(err)
@@ -161,6 +257,48 @@ var _ = ginkgo.Describe("Handler test on nerrors calls", func() {
 			gomega.Expect(converted.StackTrace).ShouldNot(gomega.BeNil())
 			gomega.Expect(converted.From).Should(gomega.BeNil())
 		})
+		ginkgo.It("nil is not converted", func() {
+			gomega.Expect(FromError(nil)).Should(gomega.BeNil())
+		})
+		ginkgo.It("gRPC error can be converted to Extended error", func() {
+			grpcError := status.Error(codes.NotFound, "id was not found")
+			converted := FromError(grpcError)
+			gomega.Expect(converted.Code).Should(gomega.Equal(NotFound))
+			gomega.Expect(converted.Msg).Should(gomega.BeEmpty())
+			gomega.Expect(converted.From).Should(gomega.Equal(grpcError))
+			gomega.Expect(converted.Error()).Should(gomega.Equal("[NotFound] id was not found"))
+			gomega.Expect(status.Convert(converted.ToGRPC()).Message()).Should(gomega.Equal("id was not found"))
+		})
+		ginkgo.It("context errors can be converted to Extended error", func() {
+			canceled := FromError(context.Canceled)
+			gomega.Expect(canceled.Code).Should(gomega.Equal(Canceled))
+			gomega.Expect(canceled.From).Should(gomega.Equal(context.Canceled))
+			gomega.Expect(canceled.Error()).Should(gomega.Equal("[Canceled] context canceled"))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 0)
+			defer cancel()
+			<-ctx.Done()
+			deadline := FromError(fmt.Errorf("waiting for the operation: %w", ctx.Err()))
+			gomega.Expect(deadline.Code).Should(gomega.Equal(DeadlineExceeded))
+			gomega.Expect(errors.Is(deadline.From, context.DeadlineExceeded)).Should(gomega.BeTrue())
+		})
+		ginkgo.It("wrapped Extended error can be converted to Extended error", func() {
+			parent := NewNotFoundError("not found")
+			wrapped := fmt.Errorf("loading the record: %w", parent)
+			converted := FromError(wrapped)
+			gomega.Expect(converted.Code).Should(gomega.Equal(NotFound))
+			gomega.Expect(converted.Error()).Should(gomega.Equal("[NotFound] " + wrapped.Error()))
+			gomega.Expect(converted.From).Should(gomega.Equal(wrapped))
+			gomega.Expect(errors.Unwrap(converted.From)).Should(gomega.Equal(parent))
+		})
+		ginkgo.It("converted errors keep the cause through gRPC", func() {
+			parent := NewNotFoundError("not found")
+			converted := FromGRPC(FromError(fmt.Errorf("loading the record: %w", parent)).ToGRPC())
+			gomega.Expect(converted.Code).Should(gomega.Equal(NotFound))
+			cause := FromError(converted.From)
+			gomega.Expect(cause.Msg).Should(gomega.Equal("loading the record: [NotFound] not found"))
+			gomega.Expect(cause.From).Should(gomega.Equal(parent))
+		})
 	})
 })
 
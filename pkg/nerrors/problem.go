@@ -0,0 +1,141 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of the problem details defined in RFC 9457.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix is the prefix of the problem type URI. The name of the ErrorCode is appended to it.
+const ProblemTypePrefix = "urn:nerrors:code:"
+
+// Problem with the problem details of an error as defined in RFC 9457 (formerly RFC 7807). The error code, the
+// cause chain and the stack trace are sent as extension members.
+type Problem struct {
+	// Type with the URI identifying the problem type.
+	Type string `json:"type"`
+	// Title with a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Status with the HTTP status code.
+	Status int `json:"status,omitempty"`
+	// Detail with the explanation of this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance with the URI identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Code with the name of the error code.
+	Code string `json:"code,omitempty"`
+	// Stack with the stack trace related to where the error happened, if requested.
+	Stack []string `json:"stack,omitempty"`
+	// Causes with the errors that caused this one, from the outermost to the innermost.
+	Causes []ProblemCause `json:"causes,omitempty"`
+	// IncidentID with the incident ID assigned by a Boundary, if any.
+	IncidentID string `json:"incident_id,omitempty"`
+}
+
+// ProblemCause with an error of the cause chain of a problem.
+type ProblemCause struct {
+	// Code with the name of the error code.
+	Code string `json:"code"`
+	// Detail with the textual description of the error.
+	Detail string `json:"detail"`
+	// Stack with the stack trace related to where the error happened, if requested.
+	Stack []string `json:"stack,omitempty"`
+}
+
+// ToProblem converts the error chain into problem details without stack traces.
+func (ee *ExtendedError) ToProblem() *Problem {
+	return ee.toProblem(false)
+}
+
+// ToProblemWithStack converts the error chain into problem details including the stack traces.
+func (ee *ExtendedError) ToProblemWithStack() *Problem {
+	return ee.toProblem(true)
+}
+
+func (ee *ExtendedError) toProblem(includeStack bool) *Problem {
+	problem := &Problem{
+		Type:       ProblemTypePrefix + ee.Code.String(),
+		Title:      ee.Code.String(),
+		Status:     ee.Code.HTTPStatus(),
+		Detail:     Redact(ee.message()),
+		Code:       ee.Code.String(),
+		IncidentID: ee.IncidentID(),
+	}
+	if includeStack {
+		problem.Stack = ee.StackEntries()
+	}
+	for _, cause := range ee.causes() {
+		problemCause := ProblemCause{Code: cause.Code.String(), Detail: Redact(cause.Msg)}
+		if includeStack {
+			problemCause.Stack = cause.StackEntries()
+		}
+		problem.Causes = append(problem.Causes, problemCause)
+	}
+	return problem
+}
+
+// FromProblem creates an extended error from problem details. The code is obtained from the code extension member,
+// the problem type or the HTTP status, in that order. The program counters of the frames are not part of the problem
+// details, so they are not restored.
+func FromProblem(problem *Problem) *ExtendedError {
+	extended := &ExtendedError{
+		Code: problemCode(problem.Code, problem.Type, problem.Status),
+		Msg:  problem.Detail,
+	}
+	extended.setFrames(parseStackTrace(problem.Stack))
+	if extended.Msg == "" {
+		extended.Msg = problem.Title
+	}
+	if problem.IncidentID != "" {
+		extended.WithField(IncidentIDField, problem.IncidentID)
+	}
+	last := extended
+	for _, cause := range problem.Causes {
+		next := &ExtendedError{
+			Code: problemCode(cause.Code, "", 0),
+			Msg:  cause.Detail,
+		}
+		next.setFrames(parseStackTrace(cause.Stack))
+		last.From = next
+		last = next
+	}
+	return extended
+}
+
+// problemCode obtains the ErrorCode of a problem.
+func problemCode(code string, problemType string, status int) ErrorCode {
+	if errCode, exists := FromStringCode[code]; exists {
+		return errCode
+	}
+	if strings.HasPrefix(problemType, ProblemTypePrefix) {
+		if errCode, exists := FromStringCode[strings.TrimPrefix(problemType, ProblemTypePrefix)]; exists {
+			return errCode
+		}
+	}
+	if status != 0 {
+		return FromHTTPStatus(status)
+	}
+	return Unknown
+}
+
+// WriteProblem writes an error as problem details with the HTTP status code related to its ErrorCode. The path of
+// the request is used as the problem instance.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	extended := FromError(err)
+	problem := extended.ToProblem()
+	problem.Instance = r.URL.Path
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	setRetryAfterHeader(w, extended)
+	w.WriteHeader(problem.Status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,73 @@
+package nerrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// problemRoundTrip encodes the problem as JSON and decodes it again.
+func problemRoundTrip(problem *Problem) *Problem {
+	raw, err := json.Marshal(problem)
+	gomega.Expect(err).Should(gomega.Succeed())
+	decoded := &Problem{}
+	gomega.Expect(json.Unmarshal(raw, decoded)).Should(gomega.Succeed())
+	return decoded
+}
+
+var _ = ginkgo.Describe("Handler test on problem details", func() {
+	ginkgo.It("converts an error into problem details", func() {
+		err := NewNotFoundErrorFrom(fmt.Errorf("standard error"), "app not found")
+		problem := err.ToProblem()
+		gomega.Expect(problem.Type).Should(gomega.Equal("urn:nerrors:code:NotFound"))
+		gomega.Expect(problem.Title).Should(gomega.Equal("NotFound"))
+		gomega.Expect(problem.Status).Should(gomega.Equal(http.StatusNotFound))
+		gomega.Expect(problem.Detail).Should(gomega.Equal("app not found"))
+		gomega.Expect(problem.Stack).Should(gomega.BeEmpty())
+		gomega.Expect(problem.Causes).Should(gomega.Equal([]ProblemCause{{Code: "Unknown", Detail: "standard error"}}))
+	})
+	ginkgo.It("does not repeat the cause that gives the detail", func() {
+		_, openErr := os.Open("/this/file/does/not/exist")
+		problem := FromError(openErr).ToProblem()
+		gomega.Expect(problem.Detail).Should(gomega.Equal(openErr.Error()))
+		gomega.Expect(problem.Causes).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("includes the stack traces on request", func() {
+		err := NewInternalErrorFrom(NewNotFoundError("not found"), "internal error")
+		problem := err.ToProblemWithStack()
+		gomega.Expect(problem.Stack).Should(gomega.Equal(err.StackEntries()))
+		gomega.Expect(problem.Causes).Should(gomega.HaveLen(1))
+		gomega.Expect(problem.Causes[0].Stack).ShouldNot(gomega.BeEmpty())
+	})
+	ginkgo.It("can convert problem details to error again", func() {
+		err := NewInternalErrorFrom(NewAbortedErrorFrom(NewNotFoundError("not found"), "aborted"), "internal error")
+		converted := FromProblem(problemRoundTrip(err.ToProblemWithStack()))
+		gomega.Expect(converted.String()).Should(gomega.Equal(err.String()))
+		gomega.Expect(converted.StackTraceToString()).Should(gomega.Equal(err.StackTraceToString()))
+		gomega.Expect(converted.Frames()[0].Package).Should(gomega.Equal("github.com/napptive/nerrors/pkg/nerrors"))
+
+		withoutStack := FromProblem(problemRoundTrip(err.ToProblem()))
+		gomega.Expect(withoutStack.String()).Should(gomega.Equal(err.String()))
+	})
+	ginkgo.It("obtains the code of foreign problem details", func() {
+		gomega.Expect(FromProblem(&Problem{Type: "urn:nerrors:code:Unavailable"}).Code).Should(gomega.Equal(Unavailable))
+		gomega.Expect(FromProblem(&Problem{Type: "about:blank", Status: http.StatusForbidden}).Code).Should(gomega.Equal(PermissionDenied))
+		gomega.Expect(FromProblem(&Problem{Type: "about:blank"}).Code).Should(gomega.Equal(Unknown))
+		gomega.Expect(FromProblem(&Problem{Type: "about:blank", Title: "Forbidden"}).Msg).Should(gomega.Equal("Forbidden"))
+	})
+	ginkgo.It("writes problem details", func() {
+		recorder := httptest.NewRecorder()
+		WriteProblem(recorder, httptest.NewRequest(http.MethodGet, "/apps/test", nil), NewAlreadyExistsError("app exists"))
+		gomega.Expect(recorder.Code).Should(gomega.Equal(http.StatusConflict))
+		gomega.Expect(recorder.Header().Get("Content-Type")).Should(gomega.Equal(ProblemContentType))
+		problem := &Problem{}
+		gomega.Expect(json.Unmarshal(recorder.Body.Bytes(), problem)).Should(gomega.Succeed())
+		gomega.Expect(problem.Instance).Should(gomega.Equal("/apps/test"))
+		gomega.Expect(FromProblem(problem).Code).Should(gomega.Equal(AlreadyExists))
+	})
+})
@@ -0,0 +1,172 @@
+package nerrors
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts of a RetryPolicy that does not set it, including the first one.
+	DefaultMaxAttempts = 5
+	// DefaultInitialBackoff is the delay before the first retry of a RetryPolicy that does not set it.
+	DefaultInitialBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff is the maximum delay between attempts of a RetryPolicy that does not set it.
+	DefaultMaxBackoff = 10 * time.Second
+	// DefaultBackoffMultiplier is the factor applied to the delay after each attempt of a RetryPolicy that does not
+	// set it.
+	DefaultBackoffMultiplier = 2.0
+)
+
+// Retryable returns whether an operation that failed with the code may succeed if it is retried as it is. Only the
+// codes related to transient conditions are retryable: Unavailable, ResourceExhausted, Aborted and DeadlineExceeded.
+// Operations that are not idempotent should not be retried on DeadlineExceeded, as they may have completed.
+func (ec ErrorCode) Retryable() bool {
+	switch ec {
+	case Unavailable, ResourceExhausted, Aborted, DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// Clock with the time source used to wait between attempts.
+type Clock interface {
+	// After returns a channel that receives the current time once the duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock backed by the time package.
+type systemClock struct{}
+
+// After waits with time.After.
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// RetryPolicy with the configuration of Retry. The fields that are not set take their default values, except Jitter.
+type RetryPolicy struct {
+	// MaxAttempts with the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff with the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff with the maximum delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier with the factor applied to the delay after each attempt.
+	Multiplier float64
+	// Jitter with the fraction of the delay that is randomised, in [0, 1]. A jitter of 0.2 waits between 80% and 120%
+	// of the delay. No jitter is applied if it is not set.
+	Jitter float64
+	// Retryable decides whether a failed attempt is retried. The retryability of the code of the error is used if it
+	// is not set.
+	Retryable func(err error) bool
+	// Clock with the time source used to wait between attempts. The system clock is used if it is not set.
+	Clock Clock
+}
+
+// DefaultRetryPolicy returns the policy with the default values and a jitter of 20%.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultBackoffMultiplier,
+		Jitter:         0.2,
+	}
+}
+
+// withDefaults returns a copy of the policy with the fields that are not set replaced by their default values.
+func (rp RetryPolicy) withDefaults() RetryPolicy {
+	if rp.MaxAttempts <= 0 {
+		rp.MaxAttempts = DefaultMaxAttempts
+	}
+	if rp.InitialBackoff <= 0 {
+		rp.InitialBackoff = DefaultInitialBackoff
+	}
+	if rp.MaxBackoff <= 0 {
+		rp.MaxBackoff = DefaultMaxBackoff
+	}
+	if rp.Multiplier < 1 {
+		rp.Multiplier = DefaultBackoffMultiplier
+	}
+	if rp.Retryable == nil {
+		rp.Retryable = func(err error) bool {
+			return CodeOf(err).Retryable()
+		}
+	}
+	if rp.Clock == nil {
+		rp.Clock = systemClock{}
+	}
+	return rp
+}
+
+// backoff returns the delay before the given retry, starting at 1.
+func (rp RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(rp.InitialBackoff) * math.Pow(rp.Multiplier, float64(retry-1))
+	if delay > float64(rp.MaxBackoff) {
+		delay = float64(rp.MaxBackoff)
+	}
+	if rp.Jitter > 0 {
+		delay += delay * math.Min(rp.Jitter, 1) * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// delay returns the delay before the given retry, which is the backoff or the retry delay carried by the error of
+// the failed attempt if it is longer. The retry delay set by the server is not capped by MaxBackoff.
+func (rp RetryPolicy) delay(retry int, err error) time.Duration {
+	delay := rp.backoff(retry)
+	if retryAfter := retryAfterOf(err); retryAfter > delay {
+		return retryAfter
+	}
+	return delay
+}
+
+// Retry calls fn until it succeeds, following the policy. It stops as soon as an attempt fails with an error that is
+// not retryable, the maximum number of attempts is reached, or the context is done. The delay between attempts
+// honours the retry delay carried by the errors (see ExtendedError.WithRetryAfter). The returned error chains the
+// failures of every attempt in an ErrorList, followed by the context error if the context is done. Its code is the
+// one of the last failure, or Canceled or DeadlineExceeded if the context is done. If there was a single attempt and
+// the context is not done, its error is returned as it is.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	policy = policy.withDefaults()
+	var failures []error
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		failures = append(failures, err)
+		if ctx.Err() != nil {
+			return retryError(attempt, append(failures, ctx.Err()), contextCode(ctx.Err()))
+		}
+		if attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+			return retryError(attempt, failures, CodeOf(err))
+		}
+		select {
+		case <-ctx.Done():
+			return retryError(attempt, append(failures, ctx.Err()), contextCode(ctx.Err()))
+		case <-policy.Clock.After(policy.delay(attempt, err)):
+		}
+	}
+}
+
+// retryError returns the error with the failures of the attempts.
+func retryError(attempts int, failures []error, code ErrorCode) error {
+	if len(failures) == 1 {
+		return failures[0]
+	}
+	if attempts == 1 {
+		return NewExtendedErrorFrom(code, Join(failures...), "giving up after 1 attempt")
+	}
+	return NewExtendedErrorFrom(code, Join(failures...), "giving up after %d attempts", attempts)
+}
+
+// contextCode returns the code of the error of a done context.
+func contextCode(err error) ErrorCode {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return DeadlineExceeded
+	}
+	return Canceled
+}
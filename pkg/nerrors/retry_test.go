@@ -0,0 +1,165 @@
+package nerrors
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// fakeClock is a Clock that records the requested delays and fires immediately.
+type fakeClock struct {
+	delays []time.Duration
+	// onWait is called with each delay before firing, if set.
+	onWait func(d time.Duration)
+}
+
+// After records the delay and returns a channel that has already fired.
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	fc.delays = append(fc.delays, d)
+	if fc.onWait != nil {
+		fc.onWait(d)
+	}
+	fired := make(chan time.Time, 1)
+	fired <- time.Time{}
+	return fired
+}
+
+// failingCalls returns a function that fails with the given errors, one per call, and then succeeds.
+func failingCalls(calls *int, errs ...error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+var _ = ginkgo.Describe("Handler test on retries", func() {
+	var clock *fakeClock
+	var policy RetryPolicy
+	var calls int
+
+	ginkgo.BeforeEach(func() {
+		clock = &fakeClock{}
+		policy = RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Clock: clock}
+		calls = 0
+	})
+
+	ginkgo.It("defines the retryable codes", func() {
+		for _, code := range []ErrorCode{Unavailable, ResourceExhausted, Aborted, DeadlineExceeded} {
+			gomega.Expect(code.Retryable()).Should(gomega.BeTrue(), code.String())
+		}
+		for _, code := range []ErrorCode{OK, Canceled, Unknown, InvalidArgument, NotFound, AlreadyExists, PermissionDenied,
+			FailedPrecondition, OutOfRange, Unimplemented, Internal, DataLoss, Unauthenticated} {
+			gomega.Expect(code.Retryable()).Should(gomega.BeFalse(), code.String())
+		}
+	})
+	ginkgo.It("retries with exponential backoff until it succeeds", func() {
+		err := Retry(context.Background(), policy, failingCalls(&calls,
+			NewUnavailableError("down"), NewAbortedError("conflict"), NewResourceExhaustedError("quota")))
+		gomega.Expect(err).Should(gomega.Succeed())
+		gomega.Expect(calls).Should(gomega.Equal(4))
+		gomega.Expect(clock.delays).Should(gomega.Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}))
+	})
+	ginkgo.It("caps the backoff", func() {
+		policy.MaxAttempts = 5
+		_ = Retry(context.Background(), policy, func(ctx context.Context) error {
+			return NewUnavailableError("down")
+		})
+		gomega.Expect(clock.delays).Should(gomega.Equal([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}))
+	})
+	ginkgo.It("applies the jitter", func() {
+		policy.Jitter = 0.5
+		policy.MaxAttempts = 50
+		policy.MaxBackoff = time.Second
+		_ = Retry(context.Background(), policy, func(ctx context.Context) error {
+			return NewUnavailableError("down")
+		})
+		gomega.Expect(clock.delays).Should(gomega.HaveLen(49))
+		for _, delay := range clock.delays {
+			gomega.Expect(delay).Should(gomega.BeNumerically(">=", 500*time.Millisecond))
+			gomega.Expect(delay).Should(gomega.BeNumerically("<=", 1500*time.Millisecond))
+		}
+		gomega.Expect(clock.delays).ShouldNot(gomega.ConsistOf(time.Second))
+	})
+	ginkgo.It("chains the failures of every attempt", func() {
+		err := Retry(context.Background(), policy, func(ctx context.Context) error {
+			calls++
+			return NewUnavailableError("attempt %d failed", calls)
+		})
+		gomega.Expect(calls).Should(gomega.Equal(4))
+		gomega.Expect(CodeOf(err)).Should(gomega.Equal(Unavailable))
+		gomega.Expect(err.Error()).Should(gomega.HavePrefix("[Unavailable] giving up after 4 attempts"))
+		list := err.(*ExtendedError).From.(*ErrorList)
+		gomega.Expect(list.Errors).Should(gomega.HaveLen(4))
+		gomega.Expect(list.Errors[3].(*ExtendedError).Msg).Should(gomega.Equal("attempt 4 failed"))
+	})
+	ginkgo.It("stops on the errors that are not retryable", func() {
+		err := Retry(context.Background(), policy, failingCalls(&calls,
+			NewUnavailableError("down"), NewNotFoundError("app not found")))
+		gomega.Expect(calls).Should(gomega.Equal(2))
+		gomega.Expect(CodeOf(err)).Should(gomega.Equal(NotFound))
+		gomega.Expect(errors.Is(err, ErrUnavailable)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("returns the error of a single attempt as it is", func() {
+		notFound := NewNotFoundError("app not found")
+		gomega.Expect(Retry(context.Background(), policy, failingCalls(&calls, notFound))).Should(gomega.BeIdenticalTo(notFound))
+		gomega.Expect(clock.delays).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("uses the retryable function of the policy", func() {
+		policy.Retryable = func(err error) bool {
+			return CodeOf(err) == Internal
+		}
+		err := Retry(context.Background(), policy, failingCalls(&calls, NewInternalError("flaky"), NewUnavailableError("down")))
+		gomega.Expect(calls).Should(gomega.Equal(2))
+		gomega.Expect(CodeOf(err)).Should(gomega.Equal(Unavailable))
+	})
+	ginkgo.It("stops when the context is canceled while waiting", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock.onWait = func(d time.Duration) {
+			cancel()
+		}
+		policy.Clock = &blockingClock{clock: clock}
+		err := Retry(ctx, policy, func(ctx context.Context) error {
+			calls++
+			return NewUnavailableError("down")
+		})
+		gomega.Expect(calls).Should(gomega.Equal(1))
+		gomega.Expect(CodeOf(err)).Should(gomega.Equal(Canceled))
+		gomega.Expect(errors.Is(err, context.Canceled)).Should(gomega.BeTrue())
+		gomega.Expect(errors.Is(err, ErrUnavailable)).Should(gomega.BeTrue())
+	})
+	ginkgo.It("stops when the context is done after an attempt", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := Retry(ctx, policy, func(ctx context.Context) error {
+			cancel()
+			return NewUnavailableError("down")
+		})
+		gomega.Expect(CodeOf(err)).Should(gomega.Equal(Canceled))
+		gomega.Expect(clock.delays).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("uses the default values", func() {
+		policy = RetryPolicy{Clock: clock}
+		_ = Retry(context.Background(), policy, func(ctx context.Context) error {
+			return NewUnavailableError("down")
+		})
+		gomega.Expect(clock.delays).Should(gomega.HaveLen(DefaultMaxAttempts - 1))
+		gomega.Expect(clock.delays[0]).Should(gomega.Equal(DefaultInitialBackoff))
+	})
+})
+
+// blockingClock is a Clock that records the delays with the wrapped fake clock but never fires.
+type blockingClock struct {
+	clock *fakeClock
+}
+
+// After records the delay and returns a channel that never fires.
+func (bc *blockingClock) After(d time.Duration) <-chan time.Time {
+	bc.clock.After(d)
+	return make(chan time.Time)
+}
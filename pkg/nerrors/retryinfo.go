@@ -0,0 +1,73 @@
+package nerrors
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// WithRetryAfter sets the delay the caller should wait before retrying the operation that failed. It returns the
+// error to allow chaining calls when the error is created:
+//
+//	return nerrors.NewResourceExhaustedError("quota exceeded").WithRetryAfter(30 * time.Second)
+func (ee *ExtendedError) WithRetryAfter(d time.Duration) *ExtendedError {
+	ee.retryAfter = d
+	return ee
+}
+
+// RetryAfter returns the delay the caller should wait before retrying. It is the one of the error, or the one of the
+// closest cause that sets it. It returns zero if no error of the chain sets it.
+func (ee *ExtendedError) RetryAfter() time.Duration {
+	var current error = ee
+	for current != nil {
+		if extended, ok := current.(*ExtendedError); ok && extended.retryAfter > 0 {
+			return extended.retryAfter
+		}
+		current = errors.Unwrap(current)
+	}
+	return 0
+}
+
+// retryAfterOf returns the retry delay carried by any error, including the gRPC status errors with a RetryInfo
+// detail that were not converted with FromGRPC.
+func retryAfterOf(err error) time.Duration {
+	var extended *ExtendedError
+	if errors.As(err, &extended) {
+		return extended.RetryAfter()
+	}
+	var se grpcStatus
+	if errors.As(err, &se) {
+		return retryAfterFromDetails(se.GRPCStatus().Details())
+	}
+	return 0
+}
+
+// toRetryInfo returns the google.rpc.RetryInfo detail with the retry delay of the error chain, or nil if there is none.
+func (ee *ExtendedError) toRetryInfo() *errdetails.RetryInfo {
+	if retryAfter := ee.RetryAfter(); retryAfter > 0 {
+		return &errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}
+	}
+	return nil
+}
+
+// retryAfterFromDetails returns the delay of the first google.rpc.RetryInfo detail, or zero if there is none.
+func retryAfterFromDetails(details []interface{}) time.Duration {
+	for _, detail := range details {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.RetryDelay != nil {
+			return info.RetryDelay.AsDuration()
+		}
+	}
+	return 0
+}
+
+// setRetryAfterHeader sets the Retry-After header in seconds, rounded up, if the error carries a retry delay.
+func setRetryAfterHeader(w http.ResponseWriter, ee *ExtendedError) {
+	if retryAfter := ee.RetryAfter(); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+}
@@ -0,0 +1,117 @@
+package nerrors
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var _ = ginkgo.Describe("Handler test on retry delays", func() {
+	ginkgo.It("reads the retry delay of the chain", func() {
+		cause := NewResourceExhaustedError("quota exceeded").WithRetryAfter(30 * time.Second)
+		gomega.Expect(cause.RetryAfter()).Should(gomega.Equal(30 * time.Second))
+		gomega.Expect(NewInternalErrorFrom(cause, "cannot deploy").RetryAfter()).Should(gomega.Equal(30 * time.Second))
+		gomega.Expect(NewInternalErrorFrom(cause, "cannot deploy").WithRetryAfter(time.Second).RetryAfter()).Should(gomega.Equal(time.Second))
+		gomega.Expect(NewInternalError("internal").RetryAfter()).Should(gomega.BeZero())
+	})
+
+	ginkgo.Context("gRPC", func() {
+		var previous Config
+
+		ginkgo.BeforeEach(func() {
+			previous = GetConfig()
+			SetConfig(Config{FrameDetails: true})
+		})
+		ginkgo.AfterEach(func() {
+			SetConfig(previous)
+		})
+
+		ginkgo.It("adds the RetryInfo detail after the error frames", func() {
+			err := NewResourceExhaustedErrorFrom(NewNotFoundError("quota not found"), "quota exceeded").WithRetryAfter(1500 * time.Millisecond)
+			details := status.Convert(err.ToGRPC()).Details()
+			gomega.Expect(details).Should(gomega.HaveLen(3))
+			gomega.Expect(details[0]).Should(gomega.BeAssignableToTypeOf(&nerrorspb.ErrorFrame{}))
+			gomega.Expect(details[1]).Should(gomega.BeAssignableToTypeOf(&nerrorspb.ErrorFrame{}))
+			gomega.Expect(details[2].(*errdetails.RetryInfo).RetryDelay.AsDuration()).Should(gomega.Equal(1500 * time.Millisecond))
+		})
+		ginkgo.It("restores the retry delay", func() {
+			err := NewResourceExhaustedError("quota exceeded").WithRetryAfter(time.Minute)
+			received := FromGRPC(err.ToGRPC())
+			gomega.Expect(received.RetryAfter()).Should(gomega.Equal(time.Minute))
+			gomega.Expect(received).Should(gomega.Equal(err))
+		})
+		ginkgo.It("reads the retry delay of the statuses without error frames", func() {
+			st, err := status.New(codes.Unavailable, "overloaded").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(5 * time.Second)})
+			gomega.Expect(err).Should(gomega.Succeed())
+			received := FromGRPC(st.Err())
+			gomega.Expect(received.Code).Should(gomega.Equal(Unavailable))
+			gomega.Expect(received.RetryAfter()).Should(gomega.Equal(5 * time.Second))
+		})
+		ginkgo.It("keeps the RetryInfo detail when the frames are reduced", func() {
+			SetConfig(Config{MaxDepth: DefaultStackDepth, MaxDetailsSize: 300, FrameDetails: true})
+			err := longChain(5).WithRetryAfter(time.Second)
+			converted := status.Convert(err.ToGRPC())
+			gomega.Expect(converted.Details()[len(converted.Details())-1]).Should(gomega.BeAssignableToTypeOf(&errdetails.RetryInfo{}))
+			gomega.Expect(FromGRPC(converted.Err()).RetryAfter()).Should(gomega.Equal(time.Second))
+		})
+	})
+
+	ginkgo.Context("HTTP", func() {
+		ginkgo.It("sets the Retry-After header", func() {
+			response := serve(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return NewUnavailableError("overloaded").WithRetryAfter(1500 * time.Millisecond)
+			}), http.MethodGet)
+			gomega.Expect(response.Code).Should(gomega.Equal(http.StatusServiceUnavailable))
+			gomega.Expect(response.Header().Get("Retry-After")).Should(gomega.Equal("2"))
+		})
+		ginkgo.It("sets the Retry-After header of the problem details", func() {
+			response := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				WriteProblem(w, r, NewResourceExhaustedError("quota exceeded").WithRetryAfter(time.Minute))
+			}), http.MethodGet)
+			gomega.Expect(response.Header().Get("Retry-After")).Should(gomega.Equal("60"))
+		})
+		ginkgo.It("omits the Retry-After header without retry delay", func() {
+			response := serve(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return NewUnavailableError("overloaded")
+			}), http.MethodGet)
+			gomega.Expect(response.Header().Values("Retry-After")).Should(gomega.BeEmpty())
+		})
+		ginkgo.It("keeps the retry delay of the sanitised errors", func() {
+			var incidents []loggedIncident
+			sanitized := testBoundary(&incidents).Sanitize(NewInternalErrorFrom(
+				NewUnavailableError("db-1 is down").WithRetryAfter(time.Second), "query failed"))
+			gomega.Expect(sanitized.RetryAfter()).Should(gomega.Equal(time.Second))
+		})
+	})
+
+	ginkgo.Context("Retry", func() {
+		ginkgo.It("waits for the retry delay of the errors", func() {
+			clock := &fakeClock{}
+			calls := 0
+			err := Retry(context.Background(), RetryPolicy{InitialBackoff: time.Second, Clock: clock}, failingCalls(&calls,
+				NewResourceExhaustedError("quota exceeded").WithRetryAfter(time.Minute),
+				NewUnavailableError("overloaded").WithRetryAfter(time.Millisecond),
+				status.Error(codes.Unavailable, "overloaded")))
+			gomega.Expect(err).Should(gomega.Succeed())
+			gomega.Expect(clock.delays).Should(gomega.Equal([]time.Duration{time.Minute, 2 * time.Second, 4 * time.Second}))
+		})
+		ginkgo.It("waits for the retry delay of the gRPC status errors", func() {
+			previous := GetConfig()
+			defer SetConfig(previous)
+			SetConfig(Config{FrameDetails: true})
+			clock := &fakeClock{}
+			calls := 0
+			received := NewUnavailableError("overloaded").WithRetryAfter(time.Hour).ToGRPC()
+			_ = Retry(context.Background(), RetryPolicy{InitialBackoff: time.Second, Clock: clock}, failingCalls(&calls, received))
+			gomega.Expect(clock.delays).Should(gomega.Equal([]time.Duration{time.Hour}))
+		})
+	})
+})
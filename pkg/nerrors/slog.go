@@ -0,0 +1,76 @@
+//go:build go1.21
+// +build go1.21
+
+package nerrors
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+)
+
+// slogValue returns the representation of an error of the chain as a slog group. The errors aggregated by an
+// ErrorList are written as a group keyed by their index, as slog has no arrays.
+func slogValue(entry *LogEntry) slog.Value {
+	var attrs []slog.Attr
+	if entry.Code != "" {
+		attrs = append(attrs, slog.String("code", entry.Code))
+	}
+	if entry.Type != "" {
+		attrs = append(attrs, slog.String("type", entry.Type))
+	}
+	attrs = append(attrs, slog.String("message", entry.Message))
+	if len(entry.Fields) > 0 {
+		keys := make([]string, 0, len(entry.Fields))
+		for key := range entry.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]slog.Attr, 0, len(keys))
+		for _, key := range keys {
+			fields = append(fields, slog.Any(key, entry.Fields[key]))
+		}
+		attrs = append(attrs, slog.Attr{Key: "fields", Value: slog.GroupValue(fields...)})
+	}
+	if len(entry.Stack) > 0 {
+		attrs = append(attrs, slog.Any("stack", entry.Stack))
+	}
+	if entry.Cause != nil {
+		attrs = append(attrs, slog.Attr{Key: "cause", Value: slogValue(entry.Cause)})
+	}
+	if len(entry.Causes) > 0 {
+		causes := make([]slog.Attr, 0, len(entry.Causes))
+		for index, cause := range entry.Causes {
+			causes = append(causes, slog.Attr{Key: strconv.Itoa(index), Value: slogValue(cause)})
+		}
+		attrs = append(attrs, slog.Attr{Key: "causes", Value: slog.GroupValue(causes...)})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue method to implement slog.LogValuer:
+//
+//	slog.Error("cannot deploy the app", "error", nerrors.NewLogObject(err, true))
+func (lo LogObject) LogValue() slog.Value {
+	if entry := lo.Entry(); entry != nil {
+		return slogValue(entry)
+	}
+	return slog.GroupValue()
+}
+
+// LogValue method to implement slog.LogValuer. The stack frames are not written, use NewLogObject to include them.
+func (ee *ExtendedError) LogValue() slog.Value {
+	return ee.logObject().LogValue()
+}
+
+// SlogLevel returns the slog level used to log an error based on its ErrorCode: info for cancellations, warn for the
+// errors caused by the client, and error for the failures of the server.
+func SlogLevel(err error) slog.Level {
+	switch SeverityOf(err) {
+	case SeverityInfo:
+		return slog.LevelInfo
+	case SeverityWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
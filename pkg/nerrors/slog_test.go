@@ -0,0 +1,53 @@
+//go:build go1.21
+// +build go1.21
+
+package nerrors
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Handler test on slog", func() {
+	var buffer *bytes.Buffer
+	var logger *slog.Logger
+
+	ginkgo.BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		logger = slog.New(slog.NewJSONHandler(buffer, nil))
+	})
+
+	ginkgo.It("writes the error chain as a group", func() {
+		err := NewInternalErrorFrom(Join(NewNotFoundError("image not found"), errors.New("raw failure")),
+			"cannot deploy john@example.com").WithField("app", "web")
+		logger.Error("failed", "error", err)
+		gomega.Expect(decodeLogLine(buffer.Bytes())["error"]).Should(gomega.Equal(map[string]interface{}{
+			"code":    "Internal",
+			"message": "cannot deploy [REDACTED]",
+			"fields":  map[string]interface{}{"app": "web"},
+			"cause": map[string]interface{}{
+				"type":    "*nerrors.ErrorList",
+				"message": "[NotFound] image not found; raw failure",
+				"causes": map[string]interface{}{
+					"0": map[string]interface{}{"code": "NotFound", "message": "image not found"},
+					"1": map[string]interface{}{"type": "*errors.errorString", "message": "raw failure"},
+				},
+			},
+		}))
+	})
+	ginkgo.It("writes the stack frames on request", func() {
+		err := NewInternalError("internal")
+		logger.Error("failed", "error", NewLogObject(err, true))
+		logged := decodeLogLine(buffer.Bytes())["error"].(map[string]interface{})
+		gomega.Expect(logged["stack"]).Should(gomega.HaveLen(len(err.Frames())))
+	})
+	ginkgo.It("picks the log level from the code", func() {
+		gomega.Expect(SlogLevel(NewCanceledError("canceled"))).Should(gomega.Equal(slog.LevelInfo))
+		gomega.Expect(SlogLevel(NewPermissionDeniedError("denied"))).Should(gomega.Equal(slog.LevelWarn))
+		gomega.Expect(SlogLevel(NewDataLossError("lost"))).Should(gomega.Equal(slog.LevelError))
+	})
+})
@@ -0,0 +1,124 @@
+package nerrors
+
+import (
+	"runtime"
+	"sync"
+)
+
+// getStackTrace captures the program counters of the stack when an error with the given code occurs, as set in the
+// Config. They are resolved into a stack trace only when it is needed.
+func getStackTrace(code ErrorCode) []uintptr {
+	cfg := loadConfig()
+	if !cfg.captures(code) {
+		return nil
+	}
+	buf := make([]uintptr, cfg.depth())
+	callers := runtime.Callers(2+cfg.SkipFrames, buf)
+	return buf[:callers]
+}
+
+// fillStackTrace resolves the captured stack trace into the StackTrace field unless Config.LazyStackTrace is set, for
+// the code that reads the field directly. It returns the error to be used when the error is created.
+func (ee *ExtendedError) fillStackTrace() *ExtendedError {
+	if ee.pcs == nil {
+		return ee
+	}
+	if loadConfig().LazyStackTrace {
+		ee.stackMutex = &sync.Mutex{}
+		return ee
+	}
+	ee.setFrames(resolveFrames(ee.pcs))
+	ee.pcs = nil
+	return ee
+}
+
+// setFrames sets the stack trace of the error. Unless Config.LazyStackTrace is set, it is only kept in the StackTrace
+// field, as the older versions of the library did, so the errors are equal once sent with their ErrorDetails.
+func (ee *ExtendedError) setFrames(frames StackTrace) {
+	if loadConfig().LazyStackTrace {
+		ee.frames = frames
+		return
+	}
+	ee.StackTrace = frames.Strings()
+}
+
+// Frames returns the stack trace related to where the error happened. The captured program counters are resolved the
+// first time the stack trace is needed. The entries of the StackTrace field are parsed if the error has no frames.
+func (ee *ExtendedError) Frames() StackTrace {
+	ee.lockStack()
+	defer ee.unlockStack()
+	ee.resolve()
+	if ee.frames == nil {
+		return parseStackTrace(ee.StackTrace)
+	}
+	return ee.frames
+}
+
+// StackEntries returns the stack trace related to where the error happened with the format of the StackTrace field.
+// The entries set in the StackTrace field take precedence over the captured frames.
+func (ee *ExtendedError) StackEntries() []string {
+	ee.lockStack()
+	defer ee.unlockStack()
+	ee.resolve()
+	if ee.StackTrace == nil {
+		return ee.frames.Strings()
+	}
+	return ee.StackTrace
+}
+
+// lockStack locks the stack trace of the errors whose program counters are resolved lazily. Each error has its own
+// lock, so errors resolved concurrently do not wait for each other.
+func (ee *ExtendedError) lockStack() {
+	if ee.stackMutex != nil {
+		ee.stackMutex.Lock()
+	}
+}
+
+// unlockStack unlocks the stack trace locked by lockStack.
+func (ee *ExtendedError) unlockStack() {
+	if ee.stackMutex != nil {
+		ee.stackMutex.Unlock()
+	}
+}
+
+// resolve converts the captured program counters into frames. It must be called with the stack trace locked.
+func (ee *ExtendedError) resolve() {
+	if ee.pcs != nil {
+		if ee.StackTrace == nil && ee.frames == nil {
+			ee.frames = resolveFrames(ee.pcs)
+		}
+		ee.pcs = nil
+	}
+}
+
+// resolveFrames converts the program counters into frames, applying the frame filters and the path trimming of the
+// Config.
+func resolveFrames(pcs []uintptr) StackTrace {
+	if len(pcs) == 0 {
+		return nil
+	}
+	cfg := loadConfig()
+	resolved := make(StackTrace, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		resolved = append(resolved, Frame{
+			Function: frame.Function,
+			Package:  functionPackage(frame.Function),
+			File:     frame.File,
+			Line:     frame.Line,
+			PC:       frame.PC,
+		})
+		if !more {
+			break
+		}
+	}
+	resolved = cfg.filterFrames(resolved)
+	if len(resolved) == 0 {
+		return nil
+	}
+	for i := range resolved {
+		resolved[i].File = cfg.trimPath(resolved[i].File, resolved[i].Package)
+	}
+	return resolved
+}
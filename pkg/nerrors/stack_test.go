@@ -0,0 +1,142 @@
+package nerrors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/napptive/nerrors/pkg/nerrorspb/v1"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc/status"
+)
+
+// sharedChain returns a chain of errors whose stack traces share their outermost frames.
+func sharedChain() *ExtendedError {
+	main := Frame{Function: "main.main", Package: "main", File: "main.go", Line: 10, PC: 10}
+	run := Frame{Function: "main.run", Package: "main", File: "main.go", Line: 20, PC: 20}
+	load := Frame{Function: "main.load", Package: "main", File: "main.go", Line: 30, PC: 30}
+	cause := &ExtendedError{Code: NotFound, Msg: "not found"}
+	cause.setFrames(StackTrace{{Function: "main.query", Package: "main", File: "main.go", Line: 40, PC: 40}, load, run, main})
+	err := &ExtendedError{Code: Internal, Msg: "internal", From: cause}
+	err.setFrames(StackTrace{{Function: "main.run", Package: "main", File: "main.go", Line: 21, PC: 21}, main})
+	return err
+}
+
+var _ = ginkgo.Describe("Handler test on stack traces", func() {
+	ginkgo.It("resolves the stack trace lazily on request", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{LazyStackTrace: true})
+		err := NewNotFoundError("not found")
+		gomega.Expect(err.frames).Should(gomega.BeNil())
+		gomega.Expect(err.pcs).ShouldNot(gomega.BeEmpty())
+
+		entries := err.StackEntries()
+		gomega.Expect(entries).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(entries[0]).Should(gomega.ContainSubstring("nerrors.NewExtendedError\n"))
+		gomega.Expect(strings.Join(entries, "")).Should(gomega.ContainSubstring("stack_test.go"))
+		gomega.Expect(err.frames.Strings()).Should(gomega.Equal(entries))
+		gomega.Expect(err.StackTrace).Should(gomega.BeNil())
+		gomega.Expect(err.pcs).Should(gomega.BeNil())
+	})
+	ginkgo.It("fills the StackTrace field by default", func() {
+		err := NewNotFoundError("not found")
+		gomega.Expect(err.pcs).Should(gomega.BeNil())
+		gomega.Expect(err.StackTrace).ShouldNot(gomega.BeEmpty())
+		gomega.Expect(err.StackTrace[0]).Should(gomega.ContainSubstring("nerrors.NewExtendedError\n"))
+		gomega.Expect(err.StackTrace).Should(gomega.Equal(err.Frames().Strings()))
+		gomega.Expect(FromError(errors.New("standard error")).StackTrace).ShouldNot(gomega.BeEmpty())
+	})
+	ginkgo.It("keeps the stack trace set by the user", func() {
+		err := NewNotFoundError("not found")
+		err.StackTrace = []string{"main.go:3 - main.main\n"}
+		gomega.Expect(err.StackEntries()).Should(gomega.Equal([]string{"main.go:3 - main.main\n"}))
+		gomega.Expect(err.Frames()).Should(gomega.Equal(StackTrace{{Function: "main.main", Package: "main", File: "main.go", Line: 3}}))
+	})
+	ginkgo.It("resolves the stack trace concurrently", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{LazyStackTrace: true})
+		err := NewNotFoundError("not found")
+		other := NewNotFoundError("not found")
+		gomega.Expect(err.stackMutex).ShouldNot(gomega.BeIdenticalTo(other.stackMutex))
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(target *ExtendedError) {
+				defer wg.Done()
+				defer ginkgo.GinkgoRecover()
+				gomega.Expect(target.StackTraceToString()).Should(gomega.ContainSubstring("stack_test.go"))
+			}([]*ExtendedError{err, other}[i%2])
+		}
+		wg.Wait()
+	})
+	ginkgo.It("omits the frames shared with the error caused by the cause", func() {
+		gomega.Expect(sharedChain().StackTraceToString()).Should(gomega.Equal("[Internal] internal\n" +
+			"main.go:21 - main.run\n" +
+			"main.go:10 - main.main\n" +
+			"Caused by [NotFound] not found\n" +
+			"main.go:40 - main.query\n" +
+			"main.go:30 - main.load\n" +
+			"main.go:20 - main.run\n" +
+			"... 1 more\n"))
+	})
+	ginkgo.It("omits the shared frames of a real chain", func() {
+		err := NewInternalErrorFrom(NewAbortedErrorFrom(NewNotFoundError("not found"), "aborted"), "internal")
+		gomega.Expect(strings.Count(err.StackTraceToString(), " more\n")).Should(gomega.Equal(2))
+		gomega.Expect(strings.Count(err.StackTraceToString(), "runtime.goexit")).Should(gomega.Equal(1))
+	})
+	ginkgo.It("does not repeat the cause that gives the message", func() {
+		_, openErr := os.Open("/this/file/does/not/exist")
+		gomega.Expect(FromError(openErr).StackTraceToString()).ShouldNot(gomega.ContainSubstring("Caused by"))
+
+		trace := FromError(fmt.Errorf("loading: %w", NewNotFoundError("not found"))).StackTraceToString()
+		gomega.Expect(trace).Should(gomega.HavePrefix("[NotFound] loading: [NotFound] not found\n"))
+		gomega.Expect(trace).Should(gomega.ContainSubstring("Caused by [NotFound] not found\n"))
+		gomega.Expect(trace).ShouldNot(gomega.ContainSubstring("stack trace no available"))
+	})
+	ginkgo.It("sends the shared frames only once through gRPC", func() {
+		previous := GetConfig()
+		defer SetConfig(previous)
+		SetConfig(Config{FrameDetails: true})
+		err := sharedChain()
+		details := status.Convert(err.ToGRPC()).Details()
+		gomega.Expect(details).Should(gomega.HaveLen(2))
+		cause := details[1].(*nerrorspb.ErrorFrame)
+		gomega.Expect(cause.StackFrames).Should(gomega.HaveLen(3))
+		gomega.Expect(cause.SharedFrames).Should(gomega.Equal(int32(1)))
+
+		received := FromGRPC(err.ToGRPC())
+		gomega.Expect(received).Should(gomega.Equal(err))
+	})
+	ginkgo.It("ignores invalid shared frames", func() {
+		details := []interface{}{
+			&nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode_INTERNAL, Message: "internal", CauseIndex: 1},
+			&nerrorspb.ErrorFrame{Code: nerrorspb.ErrorCode_NOT_FOUND, Message: "not found", CauseIndex: -1, SharedFrames: 3,
+				StackFrames: []*nerrorspb.StackFrame{{Function: "main.main", File: "main.go", Line: 3}}},
+		}
+		extended := ExtendedErrorFromDetail(details)
+		gomega.Expect(extended.From.(*ExtendedError).Frames()).Should(gomega.Equal(StackTrace{{Function: "main.main", Package: "main", File: "main.go", Line: 3}}))
+	})
+})
+
+// BenchmarkNewError measures the cost of creating an error whose stack trace is never used.
+func BenchmarkNewError(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewNotFoundError("record %d not found", i)
+	}
+}
+
+// BenchmarkNewErrorWithStackTrace measures the cost of creating an error and resolving its stack trace, which was
+// the cost of creating any error before the stack trace was resolved lazily.
+func BenchmarkNewErrorWithStackTrace(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewNotFoundError("record %d not found", i).StackEntries()
+	}
+}
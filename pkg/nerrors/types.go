@@ -1,6 +1,9 @@
 package nerrors
 
 import (
+	"fmt"
+	"net/http"
+
 	"google.golang.org/grpc/codes"
 )
 
@@ -45,13 +48,47 @@ const (
 	Unauthenticated
 )
 
+// codeNames with the names of the codes, indexed by their value.
+var codeNames = [...]string{"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded",
+	"NotFound", "AlreadyExists", "PermissionDenied", "ResourceExhausted",
+	"FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented", "Internal",
+	"Unavailable", "DataLoss", "Unauthenticated"}
+
+// String returns the name of the code, or ErrorCode(n) for the values that are not defined.
 func (ec ErrorCode) String() string {
-	return [...]string{"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded",
-		"NotFound", "AlreadyExists", "PermissionDenied", "ResourceExhausted",
-		"FailedPrecondition", "Aborted", "OutOfRange", "Unimplemented", "Internal",
-		"Unavailable", "DataLoss", "Unauthenticated"}[ec]
+	if ec < 0 || int(ec) >= len(codeNames) {
+		return fmt.Sprintf("ErrorCode(%d)", int(ec))
+	}
+	return codeNames[ec]
+}
+
+// Error returns the name of the code. It allows the codes to be used as targets of errors.Is, for example
+// errors.Is(err, nerrors.NotFound).
+func (ec ErrorCode) Error() string {
+	return ec.String()
 }
 
+// Sentinel errors for each ErrorCode to be used with errors.Is. They match any extended error of the chain with
+// the same code.
+var (
+	ErrCanceled           error = Canceled
+	ErrUnknown            error = Unknown
+	ErrInvalidArgument    error = InvalidArgument
+	ErrDeadlineExceeded   error = DeadlineExceeded
+	ErrNotFound           error = NotFound
+	ErrAlreadyExists      error = AlreadyExists
+	ErrPermissionDenied   error = PermissionDenied
+	ErrResourceExhausted  error = ResourceExhausted
+	ErrFailedPrecondition error = FailedPrecondition
+	ErrAborted            error = Aborted
+	ErrOutOfRange         error = OutOfRange
+	ErrUnimplemented      error = Unimplemented
+	ErrInternal           error = Internal
+	ErrUnavailable        error = Unavailable
+	ErrDataLoss           error = DataLoss
+	ErrUnauthenticated    error = Unauthenticated
+)
+
 var FromStringCode = map [string]ErrorCode {
 		"OK":                 OK,
 		"Canceled":           Canceled,
@@ -111,3 +148,69 @@ var FromGRPCCode = map[codes.Code]ErrorCode{
 	codes.DataLoss:           DataLoss,
 	codes.Unauthenticated:    Unauthenticated,
 }
+
+// httpStatus with the HTTP status code of each ErrorCode following the google.rpc.Code mapping.
+var httpStatus = map[ErrorCode]int{
+	OK:                 http.StatusOK,
+	Canceled:           499, // Client Closed Request
+	Unknown:            http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	DeadlineExceeded:   http.StatusGatewayTimeout,
+	NotFound:           http.StatusNotFound,
+	AlreadyExists:      http.StatusConflict,
+	PermissionDenied:   http.StatusForbidden,
+	ResourceExhausted:  http.StatusTooManyRequests,
+	FailedPrecondition: http.StatusBadRequest,
+	Aborted:            http.StatusConflict,
+	OutOfRange:         http.StatusBadRequest,
+	Unimplemented:      http.StatusNotImplemented,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusServiceUnavailable,
+	DataLoss:           http.StatusInternalServerError,
+	Unauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatus returns the HTTP status code related to the ErrorCode following the google.rpc.Code mapping.
+// Unknown codes are mapped to 500 Internal Server Error.
+func (ec ErrorCode) HTTPStatus() int {
+	if status, exists := httpStatus[ec]; exists {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FromHTTPStatus returns the ErrorCode related to an HTTP status code. As several codes share the same HTTP status,
+// the most generic one is returned (e.g., 400 Bad Request is mapped to InvalidArgument).
+func FromHTTPStatus(status int) ErrorCode {
+	switch {
+	case status >= 200 && status < 300:
+		return OK
+	case status == http.StatusUnauthorized:
+		return Unauthenticated
+	case status == http.StatusForbidden:
+		return PermissionDenied
+	case status == http.StatusNotFound:
+		return NotFound
+	case status == http.StatusConflict:
+		return AlreadyExists
+	case status == http.StatusPreconditionFailed:
+		return FailedPrecondition
+	case status == http.StatusRequestedRangeNotSatisfiable:
+		return OutOfRange
+	case status == http.StatusTooManyRequests:
+		return ResourceExhausted
+	case status == 499:
+		return Canceled
+	case status == http.StatusNotImplemented:
+		return Unimplemented
+	case status == http.StatusServiceUnavailable:
+		return Unavailable
+	case status == http.StatusGatewayTimeout:
+		return DeadlineExceeded
+	case status >= 400 && status < 500:
+		return InvalidArgument
+	case status >= 500 && status < 600:
+		return Internal
+	}
+	return Unknown
+}
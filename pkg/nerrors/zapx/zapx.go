@@ -0,0 +1,90 @@
+// Package zapx writes extended errors with zap. It is kept apart from the nerrors package so zap is only a
+// dependency of the projects that use it.
+package zapx
+
+import (
+	"github.com/napptive/nerrors/pkg/nerrors"
+	"go.uber.org/zap/zapcore"
+)
+
+// entryObject writes an error of the chain to a zap encoder.
+type entryObject struct {
+	entry *nerrors.LogEntry
+}
+
+// MarshalLogObject method to implement zapcore.ObjectMarshaler.
+func (eo entryObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if eo.entry.Code != "" {
+		enc.AddString("code", eo.entry.Code)
+	}
+	if eo.entry.Type != "" {
+		enc.AddString("type", eo.entry.Type)
+	}
+	enc.AddString("message", eo.entry.Message)
+	if len(eo.entry.Fields) > 0 {
+		if err := enc.AddObject("fields", zapcore.ObjectMarshalerFunc(func(fields zapcore.ObjectEncoder) error {
+			for key, value := range eo.entry.Fields {
+				if err := fields.AddReflected(key, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		})); err != nil {
+			return err
+		}
+	}
+	if len(eo.entry.Stack) > 0 {
+		if err := enc.AddReflected("stack", eo.entry.Stack); err != nil {
+			return err
+		}
+	}
+	if eo.entry.Cause != nil {
+		if err := enc.AddObject("cause", entryObject{entry: eo.entry.Cause}); err != nil {
+			return err
+		}
+	}
+	if len(eo.entry.Causes) > 0 {
+		return enc.AddArray("causes", zapcore.ArrayMarshalerFunc(func(causes zapcore.ArrayEncoder) error {
+			for _, cause := range eo.entry.Causes {
+				if err := causes.AppendObject(entryObject{entry: cause}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+	return nil
+}
+
+// logObject writes a nerrors.LogObject to a zap encoder.
+type logObject struct {
+	nerrors.LogObject
+}
+
+// MarshalLogObject method to implement zapcore.ObjectMarshaler.
+func (lo logObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if entry := lo.Entry(); entry != nil {
+		return entryObject{entry: entry}.MarshalLogObject(enc)
+	}
+	return nil
+}
+
+// Object returns the zap object of an error with its chain. The stack frames are only written if withStackTrace is
+// set:
+//
+//	logger.Error("cannot deploy the app", zap.Object("error", zapx.Object(err, true)))
+func Object(err error, withStackTrace bool) zapcore.ObjectMarshaler {
+	return logObject{LogObject: nerrors.NewLogObject(err, withStackTrace)}
+}
+
+// Level returns the zap level used to log an error based on its ErrorCode: info for cancellations, warn for the
+// errors caused by the client, and error for the failures of the server.
+func Level(err error) zapcore.Level {
+	switch nerrors.SeverityOf(err) {
+	case nerrors.SeverityInfo:
+		return zapcore.InfoLevel
+	case nerrors.SeverityWarn:
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
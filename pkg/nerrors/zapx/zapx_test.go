@@ -0,0 +1,71 @@
+package zapx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/napptive/nerrors/pkg/nerrors"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestZapx(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Zapx Suite")
+}
+
+// decodeLogLine decodes a JSON line written by a logger.
+func decodeLogLine(line []byte) map[string]interface{} {
+	decoded := map[string]interface{}{}
+	gomega.Expect(json.Unmarshal(line, &decoded)).Should(gomega.Succeed())
+	return decoded
+}
+
+var _ = ginkgo.Describe("Handler test on zap", func() {
+	var buffer *bytes.Buffer
+	var logger *zap.Logger
+	var err *nerrors.ExtendedError
+
+	ginkgo.BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+		logger = zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buffer), zapcore.DebugLevel))
+		err = nerrors.NewInternalErrorFrom(nerrors.Join(nerrors.NewNotFoundError("image not found"), errors.New("raw failure")),
+			"cannot deploy john@example.com").WithFields(map[string]interface{}{"app": "web", "replicas": 3})
+	})
+
+	ginkgo.It("writes the error chain as an object", func() {
+		logger.Error("failed", zap.Object("error", Object(err, false)))
+		gomega.Expect(decodeLogLine(buffer.Bytes())["error"]).Should(gomega.Equal(map[string]interface{}{
+			"code":    "Internal",
+			"message": "cannot deploy [REDACTED]",
+			"fields":  map[string]interface{}{"app": "web", "replicas": float64(3)},
+			"cause": map[string]interface{}{
+				"type":    "*nerrors.ErrorList",
+				"message": "[NotFound] image not found; raw failure",
+				"causes": []interface{}{
+					map[string]interface{}{"code": "NotFound", "message": "image not found"},
+					map[string]interface{}{"type": "*errors.errorString", "message": "raw failure"},
+				},
+			},
+		}))
+	})
+	ginkgo.It("writes the stack frames on request", func() {
+		logger.Error("failed", zap.Object("error", Object(err, true)))
+		logged := decodeLogLine(buffer.Bytes())["error"].(map[string]interface{})
+		gomega.Expect(logged["stack"]).Should(gomega.HaveLen(len(err.Frames())))
+	})
+	ginkgo.It("writes an empty object for nil errors", func() {
+		logger.Error("failed", zap.Object("error", Object(nil, true)))
+		gomega.Expect(decodeLogLine(buffer.Bytes())["error"]).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("picks the log level from the code", func() {
+		gomega.Expect(Level(nerrors.NewInvalidArgumentError("invalid"))).Should(gomega.Equal(zapcore.WarnLevel))
+		gomega.Expect(Level(nerrors.NewUnavailableError("unavailable"))).Should(gomega.Equal(zapcore.ErrorLevel))
+		gomega.Expect(Level(nil)).Should(gomega.Equal(zapcore.InfoLevel))
+	})
+})
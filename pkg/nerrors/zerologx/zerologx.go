@@ -0,0 +1,72 @@
+// Package zerologx writes extended errors with zerolog. It is kept apart from the nerrors package so zerolog is only
+// a dependency of the projects that use it.
+package zerologx
+
+import (
+	"github.com/napptive/nerrors/pkg/nerrors"
+	"github.com/rs/zerolog"
+)
+
+// entryObject writes an error of the chain to a zerolog event.
+type entryObject struct {
+	entry *nerrors.LogEntry
+}
+
+// MarshalZerologObject method to implement zerolog.LogObjectMarshaler.
+func (eo entryObject) MarshalZerologObject(e *zerolog.Event) {
+	if eo.entry.Code != "" {
+		e.Str("code", eo.entry.Code)
+	}
+	if eo.entry.Type != "" {
+		e.Str("type", eo.entry.Type)
+	}
+	e.Str("message", eo.entry.Message)
+	if len(eo.entry.Fields) > 0 {
+		e.Dict("fields", zerolog.Dict().Fields(eo.entry.Fields))
+	}
+	if len(eo.entry.Stack) > 0 {
+		e.Interface("stack", eo.entry.Stack)
+	}
+	if eo.entry.Cause != nil {
+		e.Object("cause", entryObject{entry: eo.entry.Cause})
+	}
+	if len(eo.entry.Causes) > 0 {
+		causes := zerolog.Arr()
+		for _, cause := range eo.entry.Causes {
+			causes.Object(entryObject{entry: cause})
+		}
+		e.Array("causes", causes)
+	}
+}
+
+// logObject writes a nerrors.LogObject to a zerolog event.
+type logObject struct {
+	nerrors.LogObject
+}
+
+// MarshalZerologObject method to implement zerolog.LogObjectMarshaler.
+func (lo logObject) MarshalZerologObject(e *zerolog.Event) {
+	if entry := lo.Entry(); entry != nil {
+		entryObject{entry: entry}.MarshalZerologObject(e)
+	}
+}
+
+// Object returns the zerolog object of an error with its chain. The stack frames are only written if withStackTrace
+// is set:
+//
+//	log.Error().Object("error", zerologx.Object(err, true)).Msg("cannot deploy the app")
+func Object(err error, withStackTrace bool) zerolog.LogObjectMarshaler {
+	return logObject{LogObject: nerrors.NewLogObject(err, withStackTrace)}
+}
+
+// Level returns the zerolog level used to log an error based on its ErrorCode: info for cancellations, warn for the
+// errors caused by the client, and error for the failures of the server.
+func Level(err error) zerolog.Level {
+	switch nerrors.SeverityOf(err) {
+	case nerrors.SeverityInfo:
+		return zerolog.InfoLevel
+	case nerrors.SeverityWarn:
+		return zerolog.WarnLevel
+	}
+	return zerolog.ErrorLevel
+}
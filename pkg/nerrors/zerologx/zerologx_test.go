@@ -0,0 +1,76 @@
+package zerologx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/napptive/nerrors/pkg/nerrors"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+)
+
+func TestZerologx(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Zerologx Suite")
+}
+
+// decodeLogLine decodes a JSON line written by a logger.
+func decodeLogLine(line []byte) map[string]interface{} {
+	decoded := map[string]interface{}{}
+	gomega.Expect(json.Unmarshal(line, &decoded)).Should(gomega.Succeed())
+	return decoded
+}
+
+var _ = ginkgo.Describe("Handler test on zerolog", func() {
+	var buffer *bytes.Buffer
+	var logger zerolog.Logger
+	var err *nerrors.ExtendedError
+
+	ginkgo.BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		logger = zerolog.New(buffer)
+		err = nerrors.NewInternalErrorFrom(nerrors.Join(nerrors.NewNotFoundError("image not found"), errors.New("raw failure")),
+			"cannot deploy john@example.com").WithFields(map[string]interface{}{"app": "web", "replicas": 3})
+	})
+
+	ginkgo.It("writes the error chain as an object", func() {
+		logger.Error().Object("error", Object(err, false)).Msg("failed")
+		gomega.Expect(decodeLogLine(buffer.Bytes())["error"]).Should(gomega.Equal(map[string]interface{}{
+			"code":    "Internal",
+			"message": "cannot deploy [REDACTED]",
+			"fields":  map[string]interface{}{"app": "web", "replicas": float64(3)},
+			"cause": map[string]interface{}{
+				"type":    "*nerrors.ErrorList",
+				"message": "[NotFound] image not found; raw failure",
+				"causes": []interface{}{
+					map[string]interface{}{"code": "NotFound", "message": "image not found"},
+					map[string]interface{}{"type": "*errors.errorString", "message": "raw failure"},
+				},
+			},
+		}))
+	})
+	ginkgo.It("writes the stack frames on request", func() {
+		logger.Error().Object("error", Object(err, true)).Msg("failed")
+		logged := decodeLogLine(buffer.Bytes())["error"].(map[string]interface{})
+		gomega.Expect(logged["stack"]).Should(gomega.HaveLen(len(err.Frames())))
+		gomega.Expect(logged["stack"].([]interface{})[0].(map[string]interface{})["function"]).Should(gomega.Equal(err.Frames()[0].Function))
+	})
+	ginkgo.It("writes the errors that are not extended errors", func() {
+		logger.Error().Object("error", Object(errors.New("raw failure"), false)).Msg("failed")
+		gomega.Expect(decodeLogLine(buffer.Bytes())["error"]).Should(gomega.Equal(map[string]interface{}{
+			"type": "*errors.errorString", "message": "raw failure"}))
+	})
+	ginkgo.It("writes an empty object for nil errors", func() {
+		logger.Error().Object("error", Object(nil, true)).Msg("failed")
+		gomega.Expect(decodeLogLine(buffer.Bytes())["error"]).Should(gomega.BeEmpty())
+	})
+	ginkgo.It("picks the log level from the code", func() {
+		gomega.Expect(Level(nerrors.NewCanceledError("canceled"))).Should(gomega.Equal(zerolog.InfoLevel))
+		gomega.Expect(Level(nerrors.NewNotFoundError("not found"))).Should(gomega.Equal(zerolog.WarnLevel))
+		gomega.Expect(Level(nerrors.NewInternalError("internal"))).Should(gomega.Equal(zerolog.ErrorLevel))
+		gomega.Expect(Level(errors.New("raw failure"))).Should(gomega.Equal(zerolog.ErrorLevel))
+	})
+})
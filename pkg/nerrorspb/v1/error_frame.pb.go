@@ -0,0 +1,462 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.13.0
+// source: nerrors/v1/error_frame.proto
+
+package nerrorspb
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// ErrorCode enumeration with the type of the error. The values are compatible with the gRPC codes.
+type ErrorCode int32
+
+const (
+	ErrorCode_OK                  ErrorCode = 0
+	ErrorCode_CANCELED            ErrorCode = 1
+	ErrorCode_UNKNOWN             ErrorCode = 2
+	ErrorCode_INVALID_ARGUMENT    ErrorCode = 3
+	ErrorCode_DEADLINE_EXCEEDED   ErrorCode = 4
+	ErrorCode_NOT_FOUND           ErrorCode = 5
+	ErrorCode_ALREADY_EXISTS      ErrorCode = 6
+	ErrorCode_PERMISSION_DENIED   ErrorCode = 7
+	ErrorCode_RESOURCE_EXHAUSTED  ErrorCode = 8
+	ErrorCode_FAILED_PRECONDITION ErrorCode = 9
+	ErrorCode_ABORTED             ErrorCode = 10
+	ErrorCode_OUT_OF_RANGE        ErrorCode = 11
+	ErrorCode_UNIMPLEMENTED       ErrorCode = 12
+	ErrorCode_INTERNAL            ErrorCode = 13
+	ErrorCode_UNAVAILABLE         ErrorCode = 14
+	ErrorCode_DATA_LOSS           ErrorCode = 15
+	ErrorCode_UNAUTHENTICATED     ErrorCode = 16
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0:  "OK",
+		1:  "CANCELED",
+		2:  "UNKNOWN",
+		3:  "INVALID_ARGUMENT",
+		4:  "DEADLINE_EXCEEDED",
+		5:  "NOT_FOUND",
+		6:  "ALREADY_EXISTS",
+		7:  "PERMISSION_DENIED",
+		8:  "RESOURCE_EXHAUSTED",
+		9:  "FAILED_PRECONDITION",
+		10: "ABORTED",
+		11: "OUT_OF_RANGE",
+		12: "UNIMPLEMENTED",
+		13: "INTERNAL",
+		14: "UNAVAILABLE",
+		15: "DATA_LOSS",
+		16: "UNAUTHENTICATED",
+	}
+	ErrorCode_value = map[string]int32{
+		"OK":                  0,
+		"CANCELED":            1,
+		"UNKNOWN":             2,
+		"INVALID_ARGUMENT":    3,
+		"DEADLINE_EXCEEDED":   4,
+		"NOT_FOUND":           5,
+		"ALREADY_EXISTS":      6,
+		"PERMISSION_DENIED":   7,
+		"RESOURCE_EXHAUSTED":  8,
+		"FAILED_PRECONDITION": 9,
+		"ABORTED":             10,
+		"OUT_OF_RANGE":        11,
+		"UNIMPLEMENTED":       12,
+		"INTERNAL":            13,
+		"UNAVAILABLE":         14,
+		"DATA_LOSS":           15,
+		"UNAUTHENTICATED":     16,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_nerrors_v1_error_frame_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_nerrors_v1_error_frame_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_nerrors_v1_error_frame_proto_rawDescGZIP(), []int{0}
+}
+
+// StackFrame with an entry of the stack trace related to where the error happened.
+type StackFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Function with the fully qualified name of the function.
+	Function string `protobuf:"bytes,1,opt,name=function,proto3" json:"function,omitempty"`
+	// File with the path of the source file.
+	File string `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	// Line with the line number in the source file.
+	Line int64 `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	// Package with the path of the package of the function.
+	Package string `protobuf:"bytes,4,opt,name=package,proto3" json:"package,omitempty"`
+	// Pc with the program counter of the frame. It is only meaningful in the process that captured it.
+	Pc uint64 `protobuf:"varint,5,opt,name=pc,proto3" json:"pc,omitempty"`
+}
+
+func (x *StackFrame) Reset() {
+	*x = StackFrame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_nerrors_v1_error_frame_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StackFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StackFrame) ProtoMessage() {}
+
+func (x *StackFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_nerrors_v1_error_frame_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StackFrame.ProtoReflect.Descriptor instead.
+func (*StackFrame) Descriptor() ([]byte, []int) {
+	return file_nerrors_v1_error_frame_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StackFrame) GetFunction() string {
+	if x != nil {
+		return x.Function
+	}
+	return ""
+}
+
+func (x *StackFrame) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *StackFrame) GetLine() int64 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *StackFrame) GetPackage() string {
+	if x != nil {
+		return x.Package
+	}
+	return ""
+}
+
+func (x *StackFrame) GetPc() uint64 {
+	if x != nil {
+		return x.Pc
+	}
+	return 0
+}
+
+// ErrorFrame with the information of one error of the chain. A gRPC status carries one ErrorFrame detail per
+// error in the chain, the first one being the outermost error.
+type ErrorFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Code with the type of the error.
+	Code ErrorCode `protobuf:"varint,1,opt,name=code,proto3,enum=nerrors.v1.ErrorCode" json:"code,omitempty"`
+	// Message with a textual description of the error.
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// StackFrames related to where the error happened in the code base.
+	StackFrames []*StackFrame `protobuf:"bytes,3,rep,name=stack_frames,json=stackFrames,proto3" json:"stack_frames,omitempty"`
+	// CauseIndex with the position in the status details of the error that caused this one, or -1 if there is none.
+	CauseIndex int32 `protobuf:"varint,4,opt,name=cause_index,json=causeIndex,proto3" json:"cause_index,omitempty"`
+	// Fields with the structured key/value information attached to the error.
+	Fields map[string]*structpb.Value `protobuf:"bytes,5,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// CauseIndices with the positions in the status details of the errors that caused this one when it aggregates
+	// several independent errors. The cause index is -1 in that case.
+	CauseIndices []int32 `protobuf:"varint,6,rep,packed,name=cause_indices,json=causeIndices,proto3" json:"cause_indices,omitempty"`
+	// SharedFrames with the number of frames at the end of the stack trace that are shared with the error this one is
+	// the cause of. They are omitted from the stack frames, and must be copied from the end of the stack trace of that
+	// error.
+	SharedFrames int32 `protobuf:"varint,7,opt,name=shared_frames,json=sharedFrames,proto3" json:"shared_frames,omitempty"`
+}
+
+func (x *ErrorFrame) Reset() {
+	*x = ErrorFrame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_nerrors_v1_error_frame_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrorFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorFrame) ProtoMessage() {}
+
+func (x *ErrorFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_nerrors_v1_error_frame_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorFrame.ProtoReflect.Descriptor instead.
+func (*ErrorFrame) Descriptor() ([]byte, []int) {
+	return file_nerrors_v1_error_frame_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ErrorFrame) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_OK
+}
+
+func (x *ErrorFrame) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ErrorFrame) GetStackFrames() []*StackFrame {
+	if x != nil {
+		return x.StackFrames
+	}
+	return nil
+}
+
+func (x *ErrorFrame) GetCauseIndex() int32 {
+	if x != nil {
+		return x.CauseIndex
+	}
+	return 0
+}
+
+func (x *ErrorFrame) GetFields() map[string]*structpb.Value {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *ErrorFrame) GetCauseIndices() []int32 {
+	if x != nil {
+		return x.CauseIndices
+	}
+	return nil
+}
+
+func (x *ErrorFrame) GetSharedFrames() int32 {
+	if x != nil {
+		return x.SharedFrames
+	}
+	return 0
+}
+
+var File_nerrors_v1_error_frame_proto protoreflect.FileDescriptor
+
+var file_nerrors_v1_error_frame_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a,
+	0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x2e, 0x76, 0x31, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75,
+	0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x7a, 0x0a, 0x0a, 0x53, 0x74, 0x61, 0x63,
+	0x6b, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61,
+	0x63, 0x6b, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x63,
+	0x6b, 0x61, 0x67, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x70, 0x63, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x02, 0x70, 0x63, 0x22, 0x86, 0x03, 0x0a, 0x0a, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x46, 0x72,
+	0x61, 0x6d, 0x65, 0x12, 0x29, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x15, 0x2e, 0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x39, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x63,
+	0x6b, 0x5f, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x63,
+	0x6b, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x46, 0x72, 0x61,
+	0x6d, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x61, 0x75, 0x73, 0x65, 0x5f, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x63, 0x61, 0x75, 0x73, 0x65, 0x49,
+	0x6e, 0x64, 0x65, 0x78, 0x12, 0x3a, 0x0a, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x2e, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73,
+	0x12, 0x23, 0x0a, 0x0d, 0x63, 0x61, 0x75, 0x73, 0x65, 0x5f, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65,
+	0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0c, 0x63, 0x61, 0x75, 0x73, 0x65, 0x49, 0x6e,
+	0x64, 0x69, 0x63, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x5f,
+	0x66, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x73, 0x68,
+	0x61, 0x72, 0x65, 0x64, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x1a, 0x51, 0x0a, 0x0b, 0x46, 0x69,
+	0x65, 0x6c, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2c, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0xbb, 0x02,
+	0x0a, 0x09, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x06, 0x0a, 0x02, 0x4f,
+	0x4b, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x45, 0x44, 0x10,
+	0x01, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x02, 0x12, 0x14,
+	0x0a, 0x10, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x41, 0x52, 0x47, 0x55, 0x4d, 0x45,
+	0x4e, 0x54, 0x10, 0x03, 0x12, 0x15, 0x0a, 0x11, 0x44, 0x45, 0x41, 0x44, 0x4c, 0x49, 0x4e, 0x45,
+	0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x04, 0x12, 0x0d, 0x0a, 0x09, 0x4e,
+	0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x05, 0x12, 0x12, 0x0a, 0x0e, 0x41, 0x4c,
+	0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x45, 0x58, 0x49, 0x53, 0x54, 0x53, 0x10, 0x06, 0x12, 0x15,
+	0x0a, 0x11, 0x50, 0x45, 0x52, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4e,
+	0x49, 0x45, 0x44, 0x10, 0x07, 0x12, 0x16, 0x0a, 0x12, 0x52, 0x45, 0x53, 0x4f, 0x55, 0x52, 0x43,
+	0x45, 0x5f, 0x45, 0x58, 0x48, 0x41, 0x55, 0x53, 0x54, 0x45, 0x44, 0x10, 0x08, 0x12, 0x17, 0x0a,
+	0x13, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x5f, 0x50, 0x52, 0x45, 0x43, 0x4f, 0x4e, 0x44, 0x49,
+	0x54, 0x49, 0x4f, 0x4e, 0x10, 0x09, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x42, 0x4f, 0x52, 0x54, 0x45,
+	0x44, 0x10, 0x0a, 0x12, 0x10, 0x0a, 0x0c, 0x4f, 0x55, 0x54, 0x5f, 0x4f, 0x46, 0x5f, 0x52, 0x41,
+	0x4e, 0x47, 0x45, 0x10, 0x0b, 0x12, 0x11, 0x0a, 0x0d, 0x55, 0x4e, 0x49, 0x4d, 0x50, 0x4c, 0x45,
+	0x4d, 0x45, 0x4e, 0x54, 0x45, 0x44, 0x10, 0x0c, 0x12, 0x0c, 0x0a, 0x08, 0x49, 0x4e, 0x54, 0x45,
+	0x52, 0x4e, 0x41, 0x4c, 0x10, 0x0d, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49,
+	0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x0e, 0x12, 0x0d, 0x0a, 0x09, 0x44, 0x41, 0x54, 0x41, 0x5f,
+	0x4c, 0x4f, 0x53, 0x53, 0x10, 0x0f, 0x12, 0x13, 0x0a, 0x0f, 0x55, 0x4e, 0x41, 0x55, 0x54, 0x48,
+	0x45, 0x4e, 0x54, 0x49, 0x43, 0x41, 0x54, 0x45, 0x44, 0x10, 0x10, 0x42, 0x38, 0x5a, 0x36, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6e, 0x61, 0x70, 0x70, 0x74, 0x69,
+	0x76, 0x65, 0x2f, 0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6e,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x70, 0x62, 0x2f, 0x76, 0x31, 0x3b, 0x6e, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_nerrors_v1_error_frame_proto_rawDescOnce sync.Once
+	file_nerrors_v1_error_frame_proto_rawDescData = file_nerrors_v1_error_frame_proto_rawDesc
+)
+
+func file_nerrors_v1_error_frame_proto_rawDescGZIP() []byte {
+	file_nerrors_v1_error_frame_proto_rawDescOnce.Do(func() {
+		file_nerrors_v1_error_frame_proto_rawDescData = protoimpl.X.CompressGZIP(file_nerrors_v1_error_frame_proto_rawDescData)
+	})
+	return file_nerrors_v1_error_frame_proto_rawDescData
+}
+
+var file_nerrors_v1_error_frame_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_nerrors_v1_error_frame_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_nerrors_v1_error_frame_proto_goTypes = []interface{}{
+	(ErrorCode)(0),         // 0: nerrors.v1.ErrorCode
+	(*StackFrame)(nil),     // 1: nerrors.v1.StackFrame
+	(*ErrorFrame)(nil),     // 2: nerrors.v1.ErrorFrame
+	nil,                    // 3: nerrors.v1.ErrorFrame.FieldsEntry
+	(*structpb.Value)(nil), // 4: google.protobuf.Value
+}
+var file_nerrors_v1_error_frame_proto_depIdxs = []int32{
+	0, // 0: nerrors.v1.ErrorFrame.code:type_name -> nerrors.v1.ErrorCode
+	1, // 1: nerrors.v1.ErrorFrame.stack_frames:type_name -> nerrors.v1.StackFrame
+	3, // 2: nerrors.v1.ErrorFrame.fields:type_name -> nerrors.v1.ErrorFrame.FieldsEntry
+	4, // 3: nerrors.v1.ErrorFrame.FieldsEntry.value:type_name -> google.protobuf.Value
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_nerrors_v1_error_frame_proto_init() }
+func file_nerrors_v1_error_frame_proto_init() {
+	if File_nerrors_v1_error_frame_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_nerrors_v1_error_frame_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StackFrame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_nerrors_v1_error_frame_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ErrorFrame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_nerrors_v1_error_frame_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_nerrors_v1_error_frame_proto_goTypes,
+		DependencyIndexes: file_nerrors_v1_error_frame_proto_depIdxs,
+		EnumInfos:         file_nerrors_v1_error_frame_proto_enumTypes,
+		MessageInfos:      file_nerrors_v1_error_frame_proto_msgTypes,
+	}.Build()
+	File_nerrors_v1_error_frame_proto = out.File
+	file_nerrors_v1_error_frame_proto_rawDesc = nil
+	file_nerrors_v1_error_frame_proto_goTypes = nil
+	file_nerrors_v1_error_frame_proto_depIdxs = nil
+}